@@ -1,130 +1,108 @@
 // main.go
 //
-// The main entrypoint for the toy microservice server.
-// This file sets up the HTTP server, routes, and logging.
+// The main entrypoint for the toy microservice server. It loads the
+// configuration and runs pkg/server until SIGINT/SIGTERM, then drains it.
 
 package main
 
 import (
 	"context"
-	"net/http"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
-	"strconv"
-	"strings"
 	"syscall"
-	"time"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/cors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
 
-	"github.com/paulcapestany/toy-service/internal/handlers"
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/loglevel"
+	"github.com/paulcapestany/toy-service/pkg/server"
 )
 
 func main() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	log.Info().Msg("Starting toy-service server")
-	// Emit a safe signal about FAKE_SECRET presence (never log the value)
-	if v := os.Getenv("FAKE_SECRET"); v != "" {
-		log.Info().Int("fakeSecretLen", len(v)).Msg("FAKE_SECRET present")
-	} else {
-		log.Info().Msg("FAKE_SECRET not set")
-	}
-
-	r := chi.NewRouter()
-
-	// Apply CORS middleware to allow local dev connections from toy-web
-	// Verbose logging is performed on handler initialization and request
-	// Just allow any origin during local dev. This can be narrowed down as needed.
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "HEAD", "OPTIONS"},
-		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"X-Request-Id"},
-		AllowCredentials: false,
-		MaxAge:           300, // 5 minutes
-	}))
-
-	// Register routes
-	r.Get("/healthz", handlers.HealthzHandler)
-	r.Post("/echo", handlers.EchoHandler)
-	r.Get("/info", handlers.InfoHandler)
-	r.Get("/version", handlers.VersionHandler)
-	// Internal (non-public) endpoint to verify secret presence without exposing values
-	r.Get("/internal/config", handlers.ConfigHandler)
-	// Reload endpoint for in-place secret reloads from mounted files
-	r.Post("/-/reload", handlers.ReloadHandler)
+	settings, configFile := loadConfig(os.Args[1:])
+	// The level was validated by config.Load.
+	level, _ := loglevel.Apply(settings.Service.LogVerbosity)
+	watchLogLevelSignals()
 
-	srv := startServer(r)
-	gracefulShutdown(srv)
-}
-
-func startServer(r *chi.Mux) *http.Server {
-	addr := resolveAddr()
+	log.Info().Str("logLevel", level.String()).Str("configFile", configFile).Msg("Starting toy-service server")
+	srv, err := server.New(server.WithConfig(settings), server.WithSocketActivation())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure server")
+	}
+	// The binary runs a single server, so it can own the global provider
+	// used by libraries that trace through the otel package.
+	otel.SetTracerProvider(srv.TracerProvider())
+	otel.SetTextMapPropagator(srv.Propagator())
+	if err := srv.Start(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("Failed to start server")
+	}
 
-	srv := &http.Server{
-		Addr:              addr,
-		Handler:           r,
-		ReadHeaderTimeout: 5 * time.Second,
-		ReadTimeout:       15 * time.Second,
-		WriteTimeout:      15 * time.Second,
-		IdleTimeout:       60 * time.Second,
+	quit := make(chan os.Signal, 2)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-quit:
+		log.Info().Msg("Received shutdown signal")
+	case err := <-srv.Err():
+		log.Fatal().Err(err).Msg("Server failed")
 	}
 
+	// A second signal skips the remaining pre-stop delay.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	go func() {
-		log.Info().Msgf("Listening on %s", srv.Addr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal().Err(err).Msg("Server failed")
+		select {
+		case <-quit:
+			cancel()
+		case <-ctx.Done():
 		}
 	}()
 
-	return srv
-}
-
-func gracefulShutdown(srv *http.Server) {
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
-	<-quit
-	log.Info().Msg("Received shutdown signal")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Error().Err(err).Msg("Graceful shutdown failed")
-	} else {
-		log.Info().Msg("Server gracefully stopped")
+		log.Error().Err(err).Msg("Server stopped uncleanly")
+		return
 	}
+	log.Info().Msg("Server gracefully stopped")
 }
 
-func resolveAddr() string {
-	raw := os.Getenv("PORT")
-	port := strings.TrimSpace(raw)
-	if port == "" {
-		return ":8080"
-	}
-
-	if strings.HasPrefix(port, ":") {
-		port = port[1:]
+// loadConfig loads the configuration from the config file, environment and
+// args (see internal/config). It exits after printing the configuration when
+// --print-config is given, and fails listing every problem when the
+// configuration is invalid. The config file in use, if any, is returned for
+// logging.
+func loadConfig(args []string) (config.Config, string) {
+	settings, flags, err := config.Load(args, os.LookupEnv, server.Validators...)
+	if errors.Is(err, flag.ErrHelp) {
+		config.Usage(os.Stdout)
+		os.Exit(0)
 	}
 
-	if port == "" {
-		log.Warn().Msg("PORT env var missing port number; defaulting to :8080")
-		return ":8080"
-	}
-
-	p, err := strconv.Atoi(port)
-	if err != nil {
-		log.Warn().Str("port", raw).Msg("Invalid PORT env var; defaulting to :8080")
-		return ":8080"
+	var invalid *config.ValidationError
+	if flags.PrintConfig && (err == nil || errors.As(err, &invalid)) {
+		if perr := config.Print(os.Stdout, settings); perr != nil {
+			log.Fatal().Err(perr).Msg("Failed to print configuration")
+		}
+		if invalid != nil {
+			for _, msg := range invalid.Messages() {
+				fmt.Fprintln(os.Stderr, "invalid:", msg)
+			}
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
 
-	if p < 1 || p > 65535 {
-		log.Warn().Str("port", raw).Msg("PORT env var out of range; defaulting to :8080")
-		return ":8080"
+	switch {
+	case errors.As(err, &invalid):
+		log.Fatal().Strs("problems", invalid.Messages()).Msg("Invalid configuration")
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		config.Usage(os.Stderr)
+		os.Exit(2)
 	}
-
-	return ":" + port
+	return settings, flags.ConfigFile
 }
@@ -1,47 +1,85 @@
 package main
 
-import "testing"
-
-func TestResolveAddr(t *testing.T) {
-	t.Run("defaultPort", func(t *testing.T) {
-		t.Setenv("PORT", "")
-		if got := resolveAddr(); got != ":8080" {
-			t.Fatalf("expected default :8080, got %s", got)
-		}
-	})
-
-	t.Run("numericPort", func(t *testing.T) {
-		t.Setenv("PORT", "9090")
-		if got := resolveAddr(); got != ":9090" {
-			t.Fatalf("expected :9090, got %s", got)
-		}
-	})
-
-	t.Run("prefixedPort", func(t *testing.T) {
-		t.Setenv("PORT", ":7070")
-		if got := resolveAddr(); got != ":7070" {
-			t.Fatalf("expected :7070, got %s", got)
-		}
-	})
-
-	t.Run("trimmedWhitespace", func(t *testing.T) {
-		t.Setenv("PORT", "  9800  ")
-		if got := resolveAddr(); got != ":9800" {
-			t.Fatalf("expected trimmed :9800, got %s", got)
-		}
-	})
-
-	t.Run("invalidPortFallsBack", func(t *testing.T) {
-		t.Setenv("PORT", "abc")
-		if got := resolveAddr(); got != ":8080" {
-			t.Fatalf("expected fallback :8080, got %s", got)
-		}
-	})
-
-	t.Run("outOfRangePortFallsBack", func(t *testing.T) {
-		t.Setenv("PORT", "70000")
-		if got := resolveAddr(); got != ":8080" {
-			t.Fatalf("expected fallback :8080 for out-of-range port, got %s", got)
-		}
-	})
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// loadConfigArgsEnv passes the arguments for loadConfig to the helper process.
+const loadConfigArgsEnv = "TOY_LOAD_CONFIG_ARGS"
+
+// TestHelperProcess runs loadConfig in a child process started by
+// runLoadConfig. It is skipped when run directly.
+func TestHelperProcess(t *testing.T) {
+	raw, ok := os.LookupEnv(loadConfigArgsEnv)
+	if !ok {
+		t.Skip("helper process for TestLoadConfig")
+	}
+	settings, file := loadConfig(strings.Fields(raw))
+	fmt.Printf("loaded port=%d file=%q\n", settings.Server.Port, file)
+	os.Exit(0)
+}
+
+// runLoadConfig runs loadConfig(args) in a child process with only env set,
+// since it exits on every path but success. It returns the exit code and
+// the standard output and error.
+func runLoadConfig(t *testing.T, args string, env ...string) (int, string, string) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append([]string{loadConfigArgsEnv + "=" + args}, env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return exit.ExitCode(), stdout.String(), stderr.String()
+	}
+	require.NoError(t, err)
+	return 0, stdout.String(), stderr.String()
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Log("Test that a valid configuration is returned")
+	code, stdout, _ := runLoadConfig(t, "--port 9090")
+	require.Equal(t, 0, code)
+	require.Contains(t, stdout, `loaded port=9090 file=""`)
+
+	t.Log("Test that --help prints the flags and exits successfully")
+	code, stdout, _ = runLoadConfig(t, "--help")
+	require.Equal(t, 0, code)
+	require.Contains(t, stdout, "Usage of toy-service:")
+	require.NotContains(t, stdout, "loaded")
+
+	t.Log("Test that --print-config prints the configuration and exits successfully")
+	code, stdout, _ = runLoadConfig(t, "--print-config --port 9090", "ADMIN_TOKEN=s3cret")
+	require.Equal(t, 0, code)
+	require.Contains(t, stdout, "port: 9090")
+	require.Contains(t, stdout, "[redacted]")
+	require.NotContains(t, stdout, "s3cret")
+	require.NotContains(t, stdout, "loaded")
+
+	t.Log("Test that --print-config still prints an invalid configuration, then fails listing the problems")
+	code, stdout, stderr := runLoadConfig(t, "--print-config", "PORT=abc")
+	require.Equal(t, 1, code)
+	require.Contains(t, stdout, "port: 8080")
+	require.Contains(t, stderr, `invalid: server.port: invalid integer (got "abc" from env PORT)`)
+
+	t.Log("Test that an invalid configuration fails listing the problems")
+	code, stdout, stderr = runLoadConfig(t, "", "PORT=abc", "ADMIN_PORT=8080")
+	require.Equal(t, 1, code)
+	require.NotContains(t, stdout, "loaded")
+	require.Contains(t, stderr, "Invalid configuration")
+	require.Contains(t, stderr, `server.port: invalid integer`)
+
+	t.Log("Test that unknown flags fail with usage")
+	code, _, stderr = runLoadConfig(t, "--no-such-flag")
+	require.Equal(t, 2, code)
+	require.Contains(t, stderr, "Usage of toy-service:")
 }
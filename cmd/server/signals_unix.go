@@ -0,0 +1,37 @@
+//go:build !windows
+
+// signals_unix.go
+//
+// Lets operators adjust log verbosity with signals: SIGUSR1 makes logging one
+// step more verbose, SIGUSR2 one step quieter.
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/paulcapestany/toy-service/internal/loglevel"
+)
+
+func watchLogLevelSignals() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		for sig := range sigs {
+			level := loglevel.Current()
+			switch sig {
+			case syscall.SIGUSR1:
+				level = loglevel.Increase()
+			case syscall.SIGUSR2:
+				level = loglevel.Decrease()
+			}
+			// Log without a level so the change is visible at any verbosity.
+			log.Log().Str("signal", sig.String()).Str("level", level.String()).Msg("Log level changed via signal")
+		}
+	}()
+}
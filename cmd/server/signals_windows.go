@@ -0,0 +1,9 @@
+//go:build windows
+
+// signals_windows.go
+//
+// SIGUSR1/SIGUSR2 do not exist on Windows; use the /-/log-level endpoint instead.
+
+package main
+
+func watchLogLevelSignals() {}
@@ -0,0 +1,128 @@
+// accesslog.go
+//
+// Provides middleware that emits one structured zerolog line per request with
+// method, route pattern, status, bytes in/out, duration, client address, user
+// agent and request ID. High-frequency probe routes such as /healthz can be
+// sampled so Kubernetes probes do not flood the logs.
+
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/paulcapestany/toy-service/internal/apiversion"
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/logctx"
+)
+
+// Options configures the access log middleware.
+type Options struct {
+	// TrustedProxies lists the peers allowed to set X-Forwarded-For.
+	TrustedProxies TrustedProxies
+	// SampledRoutes are chi route patterns subject to sampling.
+	SampledRoutes []string
+	// Prefixes are the API version prefixes (e.g. "/v1"). A route under one
+	// is sampled like its unversioned pattern, so /v1/livez follows /livez.
+	Prefixes []string
+	// SampleEvery logs one in N successful requests to SampledRoutes.
+	// Values <= 1 disable sampling. Failed requests (status >= 400) are
+	// always logged.
+	SampleEvery uint64
+}
+
+// OptionsFromConfig builds Options from the access log configuration.
+// Successful requests to /healthz and the /livez, /readyz and /startupz
+// probes are logged 1 in HealthzSample times; set Prefixes to sample them
+// under the API version prefixes too.
+func OptionsFromConfig(cfg config.AccessLog) (Options, error) {
+	proxies, err := ParseTrustedProxies(strings.Join(cfg.TrustedProxies, ","))
+	if err != nil {
+		return Options{}, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	return Options{
+		TrustedProxies: proxies,
+		SampledRoutes:  []string{"/healthz", "/livez", "/readyz", "/startupz"},
+		SampleEvery:    cfg.HealthzSample,
+	}, nil
+}
+
+// New returns the access log middleware. Install it after requestid.Middleware
+// (and the tracing middleware) so the request-scoped logger already carries the
+// request and trace IDs.
+func New(opts Options) func(http.Handler) http.Handler {
+	sampled := make(map[string]bool, len(opts.SampledRoutes))
+	for _, route := range opts.SampledRoutes {
+		sampled[route] = true
+	}
+	var counter uint64
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			body := &countingBody{ReadCloser: r.Body}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = body
+			}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			route := routePattern(r)
+			if sampled[apiversion.Unversioned(route, opts.Prefixes)] && opts.SampleEvery > 1 && status < http.StatusBadRequest {
+				if atomic.AddUint64(&counter, 1)%opts.SampleEvery != 1 {
+					return
+				}
+			}
+
+			logger := logctx.From(r.Context())
+			event := logger.Info()
+			if status >= http.StatusInternalServerError {
+				event = logger.Error()
+			}
+			event.
+				Str("method", r.Method).
+				Str("route", route).
+				Str("path", r.URL.Path).
+				Int("status", status).
+				Int64("bytesIn", body.n).
+				Int("bytesOut", ww.BytesWritten()).
+				Dur("duration", time.Since(start)).
+				Str("remoteAddr", opts.TrustedProxies.ClientIP(r)).
+				Str("userAgent", r.UserAgent()).
+				Msg("request")
+		})
+	}
+}
+
+// countingBody counts the request body bytes actually consumed by handlers.
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// routePattern returns the matched chi route pattern, or "" if none matched.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
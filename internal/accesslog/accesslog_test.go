@@ -0,0 +1,127 @@
+package accesslog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/requestid"
+)
+
+// captureLogs redirects the global logger into a buffer for the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = prev })
+	return &buf
+}
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	sc := bufio.NewScanner(buf)
+	for sc.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func newRouter(opts Options) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(requestid.Middleware)
+	r.Use(New(opts))
+	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+	r.Post("/echo/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	})
+	return r
+}
+
+func TestMiddleware_LogsOneStructuredLine(t *testing.T) {
+	t.Log("Test that each request produces one access log line with the expected fields")
+
+	buf := captureLogs(t)
+	proxies, err := ParseTrustedProxies("10.0.0.0/8")
+	require.NoError(t, err)
+	r := newRouter(Options{TrustedProxies: proxies})
+
+	req := httptest.NewRequest(http.MethodPost, "/echo/42", strings.NewReader(`{"message":"hi"}`))
+	req.RemoteAddr = "10.1.2.3:5555"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	req.Header.Set("User-Agent", "toy-web/1.0")
+	req.Header.Set(requestid.Header, "req-77")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := decodeLines(t, buf)
+	require.Len(t, lines, 1)
+	line := lines[0]
+	require.Equal(t, "request", line["message"])
+	require.Equal(t, "POST", line["method"])
+	require.Equal(t, "/echo/{id}", line["route"])
+	require.Equal(t, float64(http.StatusCreated), line["status"])
+	require.Equal(t, float64(len(`{"message":"hi"}`)), line["bytesIn"])
+	require.Equal(t, float64(len("hello")), line["bytesOut"])
+	require.Equal(t, "203.0.113.9", line["remoteAddr"])
+	require.Equal(t, "toy-web/1.0", line["userAgent"])
+	require.Equal(t, "req-77", line["requestId"])
+	require.Contains(t, line, "duration")
+}
+
+func TestMiddleware_SamplesHealthz(t *testing.T) {
+	t.Log("Test that /healthz requests are sampled 1 in N")
+
+	buf := captureLogs(t)
+	r := newRouter(Options{SampledRoutes: []string{"/healthz"}, SampleEvery: 5})
+
+	for i := 0; i < 10; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	}
+
+	require.Len(t, decodeLines(t, buf), 2)
+}
+
+func TestMiddleware_SamplesVersionedProbes(t *testing.T) {
+	t.Log("Test that a probe under a version prefix is sampled like its unversioned route")
+
+	buf := captureLogs(t)
+	r := chi.NewRouter()
+	r.Use(requestid.Middleware)
+	r.Use(New(Options{SampledRoutes: []string{"/livez"}, Prefixes: []string{"/v1"}, SampleEvery: 5}))
+	r.Route("/v1", func(r chi.Router) {
+		r.Get("/livez", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	for i := 0; i < 10; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/livez", nil))
+	}
+
+	require.Len(t, decodeLines(t, buf), 2)
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	opts, err := OptionsFromConfig(config.AccessLog{TrustedProxies: []string{"127.0.0.1", "10.0.0.0/8"}, HealthzSample: 3})
+	require.NoError(t, err)
+	require.Len(t, opts.TrustedProxies, 2)
+	require.Equal(t, uint64(3), opts.SampleEvery)
+	require.Equal(t, []string{"/healthz", "/livez", "/readyz", "/startupz"}, opts.SampledRoutes)
+
+	_, err = OptionsFromConfig(config.AccessLog{TrustedProxies: []string{"proxy"}})
+	require.Error(t, err)
+}
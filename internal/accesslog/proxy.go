@@ -0,0 +1,71 @@
+// proxy.go
+//
+// Resolves the originating client address for a request. X-Forwarded-For is
+// only honoured when the direct peer is a trusted proxy, since any client can
+// set the header.
+
+package accesslog
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/paulcapestany/toy-service/internal/netutil"
+)
+
+// TrustedProxies is the set of networks whose X-Forwarded-For headers are believed.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a comma-separated list of IP addresses and CIDR
+// ranges. Bare addresses are treated as single-host networks.
+func ParseTrustedProxies(raw string) (TrustedProxies, error) {
+	networks, err := netutil.ParseNetworks(raw)
+	if err != nil {
+		return nil, err
+	}
+	return TrustedProxies(networks), nil
+}
+
+// Contains reports whether ip belongs to any trusted network.
+func (t TrustedProxies) Contains(ip net.IP) bool {
+	return netutil.Contains(t, ip)
+}
+
+// ClientIP returns the originating client address. When the direct peer is a
+// trusted proxy, X-Forwarded-For is walked right to left and the first
+// untrusted hop is returned; otherwise the peer address itself is used.
+func (t TrustedProxies) ClientIP(r *http.Request) string {
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+
+	peerIP := net.ParseIP(peer)
+	if peerIP == nil || !t.Contains(peerIP) {
+		return peer
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			// A malformed hop cannot be trusted further; stop at the last good one.
+			break
+		}
+		client = hops[i]
+		if !t.Contains(ip) {
+			break
+		}
+	}
+	return client
+}
@@ -0,0 +1,50 @@
+package accesslog
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies("127.0.0.1, ::1 ,10.0.0.0/8,")
+	require.NoError(t, err)
+	require.Len(t, proxies, 3)
+
+	_, err = ParseTrustedProxies("not-an-ip")
+	require.Error(t, err)
+
+	_, err = ParseTrustedProxies("10.0.0.0/99")
+	require.Error(t, err)
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name   string
+		remote string
+		xff    string
+		want   string
+	}{
+		{"untrustedPeerIgnoresHeader", "198.51.100.7:1234", "203.0.113.9", "198.51.100.7"},
+		{"trustedPeerUsesHeader", "10.0.0.5:1234", "203.0.113.9", "203.0.113.9"},
+		{"skipsTrustedHops", "10.0.0.5:1234", "203.0.113.9, 10.0.0.6", "203.0.113.9"},
+		{"spoofedLeftmostIgnored", "10.0.0.5:1234", "1.2.3.4, 203.0.113.9", "203.0.113.9"},
+		{"noHeaderUsesPeer", "10.0.0.5:1234", "", "10.0.0.5"},
+		{"malformedHopStops", "10.0.0.5:1234", "203.0.113.9, garbage", "10.0.0.5"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tc.remote
+			if tc.xff != "" {
+				req.Header.Set("X-Forwarded-For", tc.xff)
+			}
+			require.Equal(t, tc.want, proxies.ClientIP(req))
+		})
+	}
+}
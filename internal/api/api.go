@@ -0,0 +1,159 @@
+// api.go
+//
+// Package api is the Go side of spec/openapi.yaml. api.gen.go is generated
+// from the spec by oapi-codegen (configured in oapi-codegen.yaml) and holds
+// the model types, a request and response type per operation and
+// StrictServerInterface. Adding or changing an operation in the spec
+// changes the interface, so the build fails until internal/handlers
+// implements it. This file wires the generated strict handler into chi
+// with the service's error handling.
+
+package api
+
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.5.1 -config oapi-codegen.yaml ../../spec/openapi.yaml
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/logctx"
+	"github.com/paulcapestany/toy-service/internal/problem"
+)
+
+var errNoResponse = errors.New("handler returned neither a response nor an error")
+
+// RegisterHandlers routes every operation in the spec to s. A method of s
+// returns one of the operation's response types, or an error: a
+// *problem.Problem (possibly wrapped) is written as is, anything else as a
+// 500. Malformed bodies get a 400, oversized ones a 413 and bad parameters
+// a validation problem naming the parameter.
+func RegisterHandlers(r chi.Router, s StrictServerInterface) {
+	strict := NewStrictHandlerWithOptions(s, []StrictMiddlewareFunc{withRequest}, StrictHTTPServerOptions{
+		RequestErrorHandlerFunc:  requestError,
+		ResponseErrorHandlerFunc: responseError,
+	})
+	HandlerWithOptions(strict, ChiServerOptions{BaseRouter: r, ErrorHandlerFunc: requestError})
+}
+
+type requestKey struct{}
+
+// HTTPRequest returns the request being served, for handlers that need
+// transport details such as the host the client connected to. It returns
+// nil outside RegisterHandlers.
+func HTTPRequest(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(requestKey{}).(*http.Request)
+	return r
+}
+
+// handlerError marks an error returned by a StrictServerInterface method,
+// as opposed to one from writing its response.
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string {
+	return e.err.Error()
+}
+
+func (e *handlerError) Unwrap() error {
+	return e.err
+}
+
+// withRequest makes the request available to handlers through HTTPRequest
+// and marks the errors they return.
+func withRequest(f StrictHandlerFunc, operationID string) StrictHandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request any) (any, error) {
+		response, err := f(context.WithValue(ctx, requestKey{}, r), w, r, request)
+		switch {
+		case err != nil:
+			return nil, &handlerError{err: err}
+		case response == nil:
+			return nil, &handlerError{err: errNoResponse}
+		}
+		return response, nil
+	}
+}
+
+// UnmarshalJSON decodes an EchoRequest, rejecting unknown fields.
+func (r *EchoRequest) UnmarshalJSON(data []byte) error {
+	type plain EchoRequest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode((*plain)(r))
+}
+
+// requestError answers a request whose parameters or body could not be
+// decoded: 413 when the body exceeded its limit, a validation problem for
+// a bad parameter, and a plain 400 otherwise.
+func requestError(w http.ResponseWriter, r *http.Request, err error) {
+	logger := requestLogger(r)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		logger.Warn().Str("path", r.URL.Path).Msg("Rejected request: payload too large")
+		problem.Error(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Payload too large (max %s)", config.ByteSize(maxBytesErr.Limit)))
+		return
+	}
+	if field, ok := paramError(err); ok {
+		problem.Write(w, r, problem.Validation("Invalid input", field))
+		return
+	}
+	logger.Error().Err(err).Str("path", r.URL.Path).Msg("Failed to decode request body")
+	problem.Error(w, r, http.StatusBadRequest, "Invalid input")
+}
+
+// paramError describes a missing or malformed parameter.
+func paramError(err error) (problem.FieldError, bool) {
+	var (
+		format     *InvalidParamFormatError
+		required   *RequiredParamError
+		header     *RequiredHeaderError
+		tooMany    *TooManyValuesForParamError
+		unmarshal  *UnmarshalingParamError
+		unescaping *UnescapedCookieParamError
+	)
+	switch {
+	case errors.As(err, &format):
+		return problem.FieldError{Field: format.ParamName, Detail: format.Err.Error()}, true
+	case errors.As(err, &required):
+		return problem.FieldError{Field: required.ParamName, Detail: "is required"}, true
+	case errors.As(err, &header):
+		return problem.FieldError{Field: header.ParamName, Detail: "is required"}, true
+	case errors.As(err, &tooMany):
+		return problem.FieldError{Field: tooMany.ParamName, Detail: "must be given once"}, true
+	case errors.As(err, &unmarshal):
+		return problem.FieldError{Field: unmarshal.ParamName, Detail: unmarshal.Err.Error()}, true
+	case errors.As(err, &unescaping):
+		return problem.FieldError{Field: unescaping.ParamName, Detail: unescaping.Err.Error()}, true
+	}
+	return problem.FieldError{}, false
+}
+
+// responseError writes the error a handler returned. Any other error came
+// from writing the response; the status line has gone out by then, so it
+// is only logged.
+func responseError(w http.ResponseWriter, r *http.Request, err error) {
+	var handlerErr *handlerError
+	if !errors.As(err, &handlerErr) {
+		requestLogger(r).Error().Err(err).Str("path", r.URL.Path).Msg("Failed to write response")
+		return
+	}
+	var p *problem.Problem
+	if errors.As(err, &p) {
+		problem.Write(w, r, p)
+		return
+	}
+	requestLogger(r).Error().Err(handlerErr.err).Str("path", r.URL.Path).Msg("Handler failed")
+	problem.Error(w, r, http.StatusInternalServerError, "internal error")
+}
+
+func requestLogger(r *http.Request) *zerolog.Logger {
+	return logctx.From(r.Context())
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/problem"
+)
+
+// fakeServer implements the operations under test; calling any other one
+// panics on the nil embedded interface.
+type fakeServer struct {
+	StrictServerInterface
+	echo  func(ctx context.Context, request EchoRequestObject) (EchoResponseObject, error)
+	livez func(ctx context.Context, request GetLivezRequestObject) (GetLivezResponseObject, error)
+}
+
+func (f *fakeServer) Echo(ctx context.Context, request EchoRequestObject) (EchoResponseObject, error) {
+	return f.echo(ctx, request)
+}
+
+func (f *fakeServer) GetLivez(ctx context.Context, request GetLivezRequestObject) (GetLivezResponseObject, error) {
+	return f.livez(ctx, request)
+}
+
+func serve(s StrictServerInterface, method, target, body string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	RegisterHandlers(r, s)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func TestRegisterHandlers_Request(t *testing.T) {
+	t.Log("Test that bodies and parameters are decoded into the request object")
+
+	var got EchoRequestObject
+	var host string
+	s := &fakeServer{echo: func(ctx context.Context, request EchoRequestObject) (EchoResponseObject, error) {
+		got = request
+		host = HTTPRequest(ctx).Host
+		return Echo200JSONResponse{Message: "ok"}, nil
+	}}
+	rec := serve(s, http.MethodPost, "http://toy.example/echo", `{"message":"hi"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"message":"ok","version":"","commit":"","env":""}`, rec.Body.String())
+	require.Equal(t, &EchoRequest{Message: "hi"}, got.Body)
+	require.Equal(t, "toy.example", host)
+
+	var verbose []*string
+	s.livez = func(ctx context.Context, request GetLivezRequestObject) (GetLivezResponseObject, error) {
+		verbose = append(verbose, request.Params.Verbose)
+		return GetLivez503JSONResponse{
+			Body:    HealthReport{Status: HealthReportStatusFail},
+			Headers: GetLivez503ResponseHeaders{CacheControl: "no-store"},
+		}, nil
+	}
+	rec = serve(s, http.MethodGet, "/livez", "")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	serve(s, http.MethodGet, "/livez?verbose", "")
+	require.Nil(t, verbose[0])
+	require.Equal(t, "", *verbose[1])
+}
+
+func TestRegisterHandlers_Errors(t *testing.T) {
+	called := false
+	s := &fakeServer{}
+	s.echo = func(ctx context.Context, request EchoRequestObject) (EchoResponseObject, error) {
+		called = true
+		return nil, nil
+	}
+
+	t.Log("Test that undecodable bodies are rejected before reaching the server")
+	rec := serve(s, http.MethodPost, "/echo", `{"message":"hi","extra":1}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.False(t, called)
+
+	for name, tc := range map[string]struct {
+		err    error
+		status int
+		detail string
+	}{
+		"problem": {problem.New(http.StatusConflict, "busy"), http.StatusConflict, "busy"},
+		"wrapped": {fmt.Errorf("echo: %w", problem.Validation("Invalid input")), http.StatusBadRequest, "Invalid input"},
+		"other":   {errors.New("boom"), http.StatusInternalServerError, "internal error"},
+		"none":    {nil, http.StatusInternalServerError, "internal error"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s.echo = func(ctx context.Context, request EchoRequestObject) (EchoResponseObject, error) {
+				return nil, tc.err
+			}
+			rec := serve(s, http.MethodPost, "/echo", `{"message":"hi"}`)
+			require.Equal(t, tc.status, rec.Code)
+			require.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+			var p problem.Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+			require.Equal(t, tc.detail, p.Detail)
+		})
+	}
+}
+
+func TestRequestError_Param(t *testing.T) {
+	t.Log("Test that parameter errors become a validation problem naming the parameter")
+
+	for name, err := range map[string]error{
+		"format":   &InvalidParamFormatError{ParamName: "verbose", Err: errors.New("bad value")},
+		"required": &RequiredParamError{ParamName: "verbose"},
+		"tooMany":  &TooManyValuesForParamError{ParamName: "verbose", Count: 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			requestError(rec, httptest.NewRequest(http.MethodGet, "/livez", nil), err)
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+			var p problem.Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+			require.Len(t, p.Errors, 1)
+			require.Equal(t, "verbose", p.Errors[0].Field)
+		})
+	}
+}
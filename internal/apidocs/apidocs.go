@@ -0,0 +1,254 @@
+// apidocs.go
+//
+// Serves the embedded OpenAPI spec at /openapi.yaml and /openapi.json, with
+// info.version and servers filled in from the running configuration, and a
+// self-contained API explorer under /docs/ so consumers can read and try the
+// API without cloning the repo. The explorer's assets are embedded too and
+// load nothing from other origins, so it works offline.
+
+package apidocs
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/netutil"
+	"github.com/paulcapestany/toy-service/internal/problem"
+)
+
+//go:embed explorer
+var explorerFiles embed.FS
+
+// ExplorerPath is where the explorer page is served.
+const ExplorerPath = "/docs/"
+
+// CacheControl is sent with the rendered spec, which follows the live
+// version and the request's origin.
+const CacheControl = "no-cache"
+
+// Options configures how the spec and explorer are served.
+type Options struct {
+	// ServerURL is listed as the spec's only server. Empty means the origin
+	// each request was made to.
+	ServerURL string
+	// TrustedProxies are the peers whose X-Forwarded-Proto and
+	// X-Forwarded-Host are believed when deriving that origin.
+	TrustedProxies []*net.IPNet
+	// BasePath is appended to the server URL: the prefix the current API
+	// version is served under, e.g. "/v1".
+	BasePath string
+	// Explorer enables the page under ExplorerPath.
+	Explorer bool
+}
+
+// OptionsFromConfig builds Options from the openapi configuration.
+func OptionsFromConfig(cfg config.OpenAPI) Options {
+	return Options{ServerURL: strings.TrimSuffix(cfg.ServerURL, "/"), Explorer: cfg.Explorer}
+}
+
+// Docs serves one spec document.
+type Docs struct {
+	spec []byte
+	cfg  config.Provider
+	opts Options
+}
+
+// New returns Docs serving spec. It fails when spec is not a YAML mapping,
+// so a broken document is caught at startup rather than on first request.
+func New(spec []byte, cfg config.Provider, opts Options) (*Docs, error) {
+	d := &Docs{spec: spec, cfg: cfg, opts: opts}
+	if _, err := d.render(&http.Request{Host: "localhost"}); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ServeYAML handles GET /openapi.yaml.
+func (d *Docs) ServeYAML(w http.ResponseWriter, r *http.Request) {
+	d.serve(w, r, "application/yaml", d.YAML)
+}
+
+// ServeJSON handles GET /openapi.json.
+func (d *Docs) ServeJSON(w http.ResponseWriter, r *http.Request) {
+	d.serve(w, r, "application/json", d.JSON)
+}
+
+// YAML renders the spec for r as YAML. Comments and key order of the source
+// document are preserved.
+func (d *Docs) YAML(r *http.Request) ([]byte, error) {
+	doc, err := d.render(r)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// JSON renders the spec for r as indented JSON.
+func (d *Docs) JSON(r *http.Request) ([]byte, error) {
+	v, err := d.Document(r)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(body, '\n'), nil
+}
+
+// Explorer serves the explorer's assets; mount it at ExplorerPath.
+func (d *Docs) Explorer() http.Handler {
+	assets, _ := fs.Sub(explorerFiles, "explorer")
+	files := http.StripPrefix(ExplorerPath, http.FileServer(http.FS(assets)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Everything the page needs comes from this origin.
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if name := strings.TrimPrefix(r.URL.Path, ExplorerPath); name != "" {
+			if _, err := fs.Stat(assets, name); err != nil {
+				problem.NotFound(w, r)
+				return
+			}
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// Document renders the spec for r as a generic JSON-compatible value.
+func (d *Docs) Document(r *http.Request) (map[string]any, error) {
+	doc, err := d.render(r)
+	if err != nil {
+		return nil, err
+	}
+	var v map[string]any
+	if err := doc.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (d *Docs) serve(w http.ResponseWriter, r *http.Request, contentType string, render func(*http.Request) ([]byte, error)) {
+	body, err := render(r)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, "failed to render OpenAPI spec")
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	d.SetHeaders(w.Header())
+	_, _ = w.Write(body)
+}
+
+// SetHeaders sets the caching headers of a rendered spec on h.
+func (d *Docs) SetHeaders(h http.Header) {
+	h.Set("Cache-Control", CacheControl)
+	if d.opts.ServerURL == "" {
+		// The server URL is taken from the request, so caches must key on it.
+		h.Set("Vary", "Host, X-Forwarded-Host, X-Forwarded-Proto")
+	}
+}
+
+// render parses the spec and overrides info.version and servers for r.
+// The document is parsed per request so concurrent requests never share
+// (and mutate) the same tree.
+func (d *Docs) render(r *http.Request) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(d.spec, &doc); err != nil {
+		return nil, fmt.Errorf("parse OpenAPI spec: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("parse OpenAPI spec: not a YAML mapping")
+	}
+	root := doc.Content[0]
+	snap := d.cfg.Load()
+
+	info := lookup(root, "info")
+	if info == nil || info.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("parse OpenAPI spec: missing info")
+	}
+	set(info, "version", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.TrimPrefix(snap.Version, "v")})
+
+	var servers yaml.Node
+	type server struct {
+		URL         string `yaml:"url"`
+		Description string `yaml:"description"`
+	}
+	if err := servers.Encode([]server{{URL: d.serverURL(r) + d.opts.BasePath, Description: snap.Env}}); err != nil {
+		return nil, err
+	}
+	set(root, "servers", &servers)
+	return &doc, nil
+}
+
+// serverURL returns the configured server URL or the origin r was made to.
+// Behind a trusted proxy the origin is the one the client used, taken from
+// X-Forwarded-Proto and X-Forwarded-Host; other peers cannot override it.
+func (d *Docs) serverURL(r *http.Request) string {
+	if d.opts.ServerURL != "" {
+		return d.opts.ServerURL
+	}
+	scheme, host := "http", r.Host
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if peer := netutil.PeerIP(r.RemoteAddr); peer != nil && netutil.Contains(d.opts.TrustedProxies, peer) {
+		if proto := strings.ToLower(forwarded(r, "X-Forwarded-Proto")); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if fwd := forwarded(r, "X-Forwarded-Host"); fwd != "" && !strings.ContainsAny(fwd, "/ \t@") {
+			host = fwd
+		}
+	}
+	return scheme + "://" + host
+}
+
+// forwarded returns the last value of an X-Forwarded-* header across all of
+// its lines: the one set by the trusted proxy in front of the service. Values
+// to its left come from earlier hops, ultimately the client, and are ignored.
+func forwarded(r *http.Request, header string) string {
+	values := r.Header.Values(header)
+	for i := len(values) - 1; i >= 0; i-- {
+		hops := strings.Split(values[i], ",")
+		for j := len(hops) - 1; j >= 0; j-- {
+			if hop := strings.TrimSpace(hops[j]); hop != "" {
+				return hop
+			}
+		}
+	}
+	return ""
+}
+
+// lookup returns the value for key in mapping, or nil.
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// set replaces the value for key in mapping, appending the key if absent.
+func set(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
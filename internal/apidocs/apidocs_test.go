@@ -0,0 +1,149 @@
+package apidocs
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/netutil"
+	"github.com/paulcapestany/toy-service/internal/problem"
+	"github.com/paulcapestany/toy-service/spec"
+)
+
+func newDocs(t *testing.T, opts Options) *Docs {
+	t.Helper()
+	d, err := New(spec.OpenAPI, config.Static(config.Snapshot{Version: "v1.2.3", Env: "staging"}), opts)
+	require.NoError(t, err)
+	return d
+}
+
+func TestServeJSON(t *testing.T) {
+	t.Log("Test that /openapi.json is a valid spec carrying the running version and request origin")
+
+	d := newDocs(t, Options{})
+	req := httptest.NewRequest(http.MethodGet, "https://api.example.com/openapi.json", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec := httptest.NewRecorder()
+	d.ServeJSON(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	require.Equal(t, "Host, X-Forwarded-Host, X-Forwarded-Proto", rec.Header().Get("Vary"))
+
+	doc, err := openapi3.NewLoader().LoadFromData(rec.Body.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+	require.Equal(t, "1.2.3", doc.Info.Version)
+	require.Len(t, doc.Servers, 1)
+	require.Equal(t, "https://api.example.com", doc.Servers[0].URL)
+	require.Equal(t, "staging", doc.Servers[0].Description)
+	require.NotNil(t, doc.Paths.Find("/echo"))
+}
+
+func TestServeYAML(t *testing.T) {
+	t.Log("Test that /openapi.yaml keeps the source document and uses the configured server URL and base path")
+
+	opts := OptionsFromConfig(config.OpenAPI{ServerURL: "https://toy.example.com/"})
+	opts.BasePath = "/v1"
+	d := newDocs(t, opts)
+	rec := httptest.NewRecorder()
+	d.ServeYAML(rec, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/yaml", rec.Header().Get("Content-Type"))
+	require.Empty(t, rec.Header().Get("Vary"), "a configured server URL does not depend on the request")
+
+	var doc struct {
+		Info struct {
+			Title   string `yaml:"title"`
+			Version string `yaml:"version"`
+		} `yaml:"info"`
+		Servers []struct {
+			URL string `yaml:"url"`
+		} `yaml:"servers"`
+	}
+	require.NoError(t, yaml.Unmarshal(rec.Body.Bytes(), &doc))
+	require.Equal(t, "Toy Microservice", doc.Info.Title)
+	require.Equal(t, "1.2.3", doc.Info.Version)
+	require.Len(t, doc.Servers, 1)
+	require.Equal(t, "https://toy.example.com/v1", doc.Servers[0].URL)
+	require.Contains(t, rec.Body.String(), "openapi: 3.0.3\ninfo:\n")
+}
+
+func TestServerURL_ForwardedHeaders(t *testing.T) {
+	t.Log("Test that X-Forwarded-Proto and X-Forwarded-Host are honoured only from trusted proxies")
+
+	proxies, err := netutil.ParseNetworks("10.0.0.0/8")
+	require.NoError(t, err)
+	d := newDocs(t, Options{TrustedProxies: proxies, BasePath: "/v1"})
+
+	for name, tc := range map[string]struct {
+		remoteAddr, proto, host string
+		want                    string
+	}{
+		"trustedProxy":       {"10.1.2.3:40000", "https", "toy.example.com", "https://toy.example.com/v1"},
+		"lastOfSeveralHops":  {"10.1.2.3:40000", "http, HTTPS", "evil.example.com, toy.example.com", "https://toy.example.com/v1"},
+		"protoOnly":          {"10.1.2.3:40000", "https", "", "https://pod.local:8080/v1"},
+		"invalidValues":      {"10.1.2.3:40000", "ftp", "evil.example.com/path", "http://pod.local:8080/v1"},
+		"untrustedPeer":      {"203.0.113.7:40000", "https", "evil.example.com", "http://pod.local:8080/v1"},
+		"noForwardedHeaders": {"10.1.2.3:40000", "", "", "http://pod.local:8080/v1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://pod.local:8080/openapi.json", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tc.proto)
+			}
+			if tc.host != "" {
+				req.Header.Set("X-Forwarded-Host", tc.host)
+			}
+			doc, err := d.Document(req)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, doc["servers"].([]any)[0].(map[string]any)["url"])
+		})
+	}
+
+	t.Log("Test that the last value wins when a header is repeated")
+	req := httptest.NewRequest(http.MethodGet, "http://pod.local:8080/openapi.json", nil)
+	req.RemoteAddr = "10.1.2.3:40000"
+	req.Header.Add("X-Forwarded-Host", "evil.example.com")
+	req.Header.Add("X-Forwarded-Host", "toy.example.com, ")
+	doc, err := d.Document(req)
+	require.NoError(t, err)
+	require.Equal(t, "http://toy.example.com/v1", doc["servers"].([]any)[0].(map[string]any)["url"])
+}
+
+func TestExplorer(t *testing.T) {
+	t.Log("Test that the explorer assets are served from this origin only")
+
+	h := newDocs(t, Options{Explorer: true}).Explorer()
+	for path, contentType := range map[string]string{
+		"/docs/":            "text/html; charset=utf-8",
+		"/docs/explorer.js": "text/javascript; charset=utf-8",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code, path)
+		require.Equal(t, contentType, rec.Header().Get("Content-Type"), path)
+		require.Equal(t, "default-src 'self'; frame-ancestors 'none'", rec.Header().Get("Content-Security-Policy"), path)
+		require.NotContains(t, rec.Body.String(), "https://", path)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/missing.js", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+}
+
+func TestNew_RejectsInvalidSpec(t *testing.T) {
+	_, err := New([]byte("- not a mapping\n"), config.Static(config.Snapshot{}), Options{})
+	require.Error(t, err)
+}
@@ -0,0 +1,218 @@
+// explorer.js
+//
+// Renders every operation in /openapi.json with a form to send it to this
+// origin and shows the response. Plain DOM code without dependencies, so
+// the page works offline and under a same-origin Content-Security-Policy.
+
+(function () {
+  "use strict";
+
+  var METHODS = ["get", "put", "post", "delete", "options", "head", "patch"];
+
+  // el creates an element; text is always set via text nodes, never HTML.
+  function el(tag, attrs) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (key) {
+      if (key === "class") {
+        node.className = attrs[key];
+      } else {
+        node.setAttribute(key, attrs[key]);
+      }
+    });
+    for (var i = 2; i < arguments.length; i++) {
+      var child = arguments[i];
+      if (child === null || child === undefined) continue;
+      node.appendChild(typeof child === "string" ? document.createTextNode(child) : child);
+    }
+    return node;
+  }
+
+  // resolve follows a local "#/components/..." reference.
+  function resolve(spec, obj) {
+    var seen = 0;
+    while (obj && obj.$ref && seen++ < 16) {
+      obj = obj.$ref.replace(/^#\//, "").split("/").reduce(function (node, key) {
+        return node ? node[key.replace(/~1/g, "/").replace(/~0/g, "~")] : undefined;
+      }, spec);
+    }
+    return obj || {};
+  }
+
+  // example builds a sample value from a schema, preferring its examples.
+  function example(spec, schema, depth) {
+    schema = resolve(spec, schema);
+    if (schema.example !== undefined) return schema.example;
+    if (depth > 5) return null;
+    switch (schema.type) {
+      case "object":
+        var out = {};
+        Object.keys(schema.properties || {}).forEach(function (name) {
+          out[name] = example(spec, schema.properties[name], depth + 1);
+        });
+        return out;
+      case "array":
+        return [example(spec, schema.items, depth + 1)];
+      case "integer":
+      case "number":
+        return 0;
+      case "boolean":
+        return false;
+      default:
+        return schema.enum ? schema.enum[0] : "string";
+    }
+  }
+
+  function pretty(text) {
+    try {
+      return JSON.stringify(JSON.parse(text), null, 2);
+    } catch (e) {
+      return text;
+    }
+  }
+
+  function renderOperation(spec, path, method, op, pathParams) {
+    var params = (pathParams || []).concat(op.parameters || []).map(function (p) {
+      return resolve(spec, p);
+    });
+    var inputs = [];
+    var body = el("div", { class: "body" });
+
+    if (op.description) body.appendChild(el("p", { class: "muted" }, op.description));
+
+    if (params.length) {
+      body.appendChild(el("h3", {}, "Parameters"));
+      params.forEach(function (p) {
+        var include = el("input", { type: "checkbox" });
+        var value = el("input", { type: "text", placeholder: p.in + (p.required ? " (required)" : "") });
+        if (p.required) include.checked = true;
+        inputs.push({ param: p, include: include, value: value });
+        body.appendChild(el("label", { title: p.description || "" }, include, " ", el("span", {}, p.name), value));
+      });
+    }
+
+    body.appendChild(el("h3", {}, "Headers"));
+    var accept = el("select", {},
+      el("option", { value: "*/*" }, "*/* (problem+json errors)"),
+      el("option", { value: "application/json" }, "application/json (legacy errors)"));
+    var requestId = el("input", { type: "text", placeholder: "generated by the server when empty" });
+    body.appendChild(el("label", {}, el("span", {}, "Accept"), accept));
+    body.appendChild(el("label", {}, el("span", {}, "X-Request-Id"), requestId));
+
+    var requestBody = null;
+    var content = op.requestBody && resolve(spec, op.requestBody).content;
+    if (content && content["application/json"]) {
+      body.appendChild(el("h3", {}, "Request body (application/json)"));
+      requestBody = el("textarea", { spellcheck: "false" });
+      requestBody.value = JSON.stringify(example(spec, content["application/json"].schema, 0), null, 2);
+      body.appendChild(requestBody);
+    }
+
+    var responses = el("table", {});
+    Object.keys(op.responses || {}).forEach(function (status) {
+      var response = resolve(spec, op.responses[status]);
+      var types = Object.keys(response.content || {}).join(", ");
+      responses.appendChild(el("tr", {},
+        el("td", {}, el("strong", {}, status)),
+        el("td", {}, response.description || ""),
+        el("td", { class: "muted" }, types)));
+    });
+    body.appendChild(el("h3", {}, "Responses"));
+    body.appendChild(responses);
+
+    var send = el("button", { type: "button" }, "Send");
+    var result = el("div", { class: "result" });
+    body.appendChild(send);
+    body.appendChild(result);
+
+    send.addEventListener("click", function () {
+      var url = basePath(spec) + path;
+      var query = [];
+      inputs.forEach(function (input) {
+        if (!input.include.checked) return;
+        var name = input.param.name;
+        var value = input.value.value;
+        if (input.param.in === "path") {
+          url = url.replace("{" + name + "}", encodeURIComponent(value));
+        } else if (input.param.in === "query") {
+          query.push(encodeURIComponent(name) + (value === "" ? "" : "=" + encodeURIComponent(value)));
+        }
+      });
+      if (query.length) url += "?" + query.join("&");
+
+      var headers = { Accept: accept.value };
+      if (requestId.value) headers["X-Request-Id"] = requestId.value;
+      inputs.forEach(function (input) {
+        if (input.include.checked && input.param.in === "header") headers[input.param.name] = input.value.value;
+      });
+      var init = { method: method.toUpperCase(), headers: headers };
+      if (requestBody) {
+        headers["Content-Type"] = "application/json";
+        init.body = requestBody.value;
+      }
+
+      result.textContent = "Sending…";
+      var started = performance.now();
+      fetch(url, init).then(function (resp) {
+        return resp.text().then(function (text) {
+          var ms = Math.round(performance.now() - started);
+          var lines = [];
+          resp.headers.forEach(function (value, name) {
+            lines.push(name + ": " + value);
+          });
+          result.textContent = "";
+          result.appendChild(el("p", { class: "status-line " + (resp.ok ? "ok" : "fail") },
+            init.method + " " + url + " → " + resp.status + " " + resp.statusText + " (" + ms + " ms)"));
+          result.appendChild(el("pre", {}, lines.sort().join("\n")));
+          result.appendChild(el("pre", {}, pretty(text) || "(empty body)"));
+        });
+      }).catch(function (err) {
+        result.textContent = "";
+        result.appendChild(el("p", { class: "status-line fail" }, "Request failed: " + err.message));
+      });
+    });
+
+    return el("details", { class: "operation" },
+      el("summary", {},
+        el("span", { class: "method " + method }, method.toUpperCase()),
+        el("span", { class: "path" }, path),
+        el("span", { class: "muted" }, op.summary || "")),
+      body);
+  }
+
+  // basePath is the path of the spec's server (e.g. "/v1"). Requests always
+  // go to this origin.
+  function basePath(spec) {
+    var server = (spec.servers || [])[0];
+    if (!server) return "";
+    return new URL(server.url, location.href).pathname.replace(/\/$/, "");
+  }
+
+  function render(spec) {
+    var info = spec.info || {};
+    document.title = (info.title || "API") + " – API Explorer";
+    document.getElementById("title").textContent = info.title || "API Explorer";
+    var server = (spec.servers || [])[0];
+    document.getElementById("meta").textContent = "Version " + (info.version || "unknown") +
+      (server ? " · " + server.url + (server.description ? " (" + server.description + ")" : "") : "");
+    document.getElementById("description").textContent = info.description || "";
+
+    var main = document.getElementById("operations");
+    main.textContent = "";
+    Object.keys(spec.paths || {}).sort().forEach(function (path) {
+      var item = spec.paths[path];
+      METHODS.forEach(function (method) {
+        if (item[method]) main.appendChild(renderOperation(spec, path, method, item[method], item.parameters));
+      });
+    });
+  }
+
+  fetch("/openapi.json", { headers: { Accept: "application/json" } })
+    .then(function (resp) {
+      if (!resp.ok) throw new Error("GET /openapi.json returned " + resp.status);
+      return resp.json();
+    })
+    .then(render)
+    .catch(function (err) {
+      document.getElementById("status").textContent = "Could not load the spec: " + err.message;
+    });
+})();
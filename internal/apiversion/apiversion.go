@@ -0,0 +1,133 @@
+// apiversion.go
+//
+// Serves the API under versioned path prefixes (e.g. /v1) and declares where
+// each version, or a single route of it, stands in its lifecycle. Requests
+// to a deprecated route get Deprecation (RFC 9745) and Sunset (RFC 8594)
+// headers and a Link to the route replacing it, and are counted so we know
+// when nobody calls it any more and it can be removed.
+
+package apiversion
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Lifecycle is the stage of a version or route. The zero value is current:
+// neither deprecated nor scheduled for removal.
+type Lifecycle struct {
+	// Deprecated is when the routes were deprecated; zero while current.
+	Deprecated time.Time
+	// Sunset is when the routes may be removed; zero when not scheduled.
+	// Routes keep being served after it: removing them is a code change.
+	Sunset time.Time
+	// Successor is the path prefix of the version replacing the routes,
+	// e.g. "/v1"; empty when there is none to link to.
+	Successor string
+}
+
+// IsDeprecated reports whether l is past the current stage.
+func (l Lifecycle) IsDeprecated() bool {
+	return !l.Deprecated.IsZero()
+}
+
+// Version is one generation of the API.
+type Version struct {
+	// Name labels the version in metrics, e.g. "v1" or "unversioned".
+	Name string
+	// Prefix is the path the version is served under, e.g. "/v1"; empty for
+	// the unversioned paths.
+	Prefix string
+	// Lifecycle applies to every route of the version without an entry in
+	// Routes.
+	Lifecycle Lifecycle
+	// Routes maps route patterns, as registered without Prefix (e.g.
+	// "/livez"), to their own lifecycle.
+	Routes map[string]Lifecycle
+}
+
+// lifecycle returns the lifecycle of route in v.
+func (v Version) lifecycle(route string) Lifecycle {
+	if l, ok := v.Routes[route]; ok {
+		return l
+	}
+	return v.Lifecycle
+}
+
+// Observer counts requests to deprecated routes. metrics.Metrics satisfies
+// it.
+type Observer interface {
+	ObserveDeprecatedRequest(version, method, route string)
+}
+
+// Mount serves every route of api (e.g. a router populated by
+// api.RegisterHandlers) on r under the prefix of each version, applying the
+// lifecycle declared for it. obs may be nil.
+func Mount(r chi.Router, api chi.Routes, obs Observer, versions ...Version) error {
+	return chi.Walk(api, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		handler = chi.Chain(middlewares...).Handler(handler)
+		for _, v := range versions {
+			h := handler
+			if l := v.lifecycle(route); l.IsDeprecated() {
+				h = deprecated(v, l, v.Prefix+route, obs)(h)
+			}
+			r.Method(method, v.Prefix+route, h)
+		}
+		return nil
+	})
+}
+
+// deprecated returns middleware announcing l on every response of route and
+// reporting the request to obs.
+func deprecated(v Version, l Lifecycle, route string, obs Observer) func(http.Handler) http.Handler {
+	deprecation := fmt.Sprintf("@%d", l.Deprecated.Unix())
+	var sunset string
+	if !l.Sunset.IsZero() {
+		sunset = l.Sunset.UTC().Format(http.TimeFormat)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("Deprecation", deprecation)
+			if sunset != "" {
+				h.Set("Sunset", sunset)
+			}
+			if l.Successor != "" {
+				h.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", l.Successor+strings.TrimPrefix(r.URL.Path, v.Prefix)))
+			}
+			if obs != nil {
+				obs.ObserveDeprecatedRequest(v.Name, r.Method, route)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Prefixes returns the non-empty prefixes of versions, longest first.
+func Prefixes(versions ...Version) []string {
+	var prefixes []string
+	for _, v := range versions {
+		if v.Prefix != "" {
+			prefixes = append(prefixes, v.Prefix)
+		}
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	return prefixes
+}
+
+// Unversioned returns path without the first of prefixes it lies under, so
+// settings written for /echo also apply to /v1/echo. Paths under none of
+// them are returned unchanged.
+func Unversioned(path string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if rest := strings.TrimPrefix(path, prefix); rest != path && strings.HasPrefix(rest, "/") {
+			return rest
+		}
+	}
+	return path
+}
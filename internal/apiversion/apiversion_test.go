@@ -0,0 +1,88 @@
+package apiversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type deprecations []string
+
+func (d *deprecations) ObserveDeprecatedRequest(version, method, route string) {
+	*d = append(*d, version+" "+method+" "+route)
+}
+
+func TestMount(t *testing.T) {
+	api := chi.NewRouter()
+	for _, route := range []string{"/echo", "/livez"} {
+		api.Get(route, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		})
+	}
+	api.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(chi.URLParam(r, "id")))
+	})
+
+	deprecated := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	var observed deprecations
+	r := chi.NewRouter()
+	require.NoError(t, Mount(r, api, &observed,
+		Version{Name: "v1", Prefix: "/v1"},
+		Version{
+			Name: "unversioned",
+			Lifecycle: Lifecycle{
+				Deprecated: deprecated,
+				Sunset:     deprecated.AddDate(0, 6, 0),
+				Successor:  "/v1",
+			},
+			Routes: map[string]Lifecycle{"/livez": {}},
+		},
+	))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code, path)
+		return rec
+	}
+
+	t.Log("Test that current routes are served under their prefix without deprecation headers")
+	for _, path := range []string{"/v1/echo", "/v1/livez", "/v1/items/7", "/livez"} {
+		rec := get(path)
+		require.Empty(t, rec.Header().Get("Deprecation"), path)
+		require.Empty(t, rec.Header().Get("Sunset"), path)
+		require.Empty(t, rec.Header().Get("Link"), path)
+	}
+	require.Equal(t, "7", get("/v1/items/7").Body.String())
+
+	t.Log("Test that deprecated routes announce their lifecycle and successor")
+	rec := get("/echo")
+	require.Equal(t, "@1792195200", rec.Header().Get("Deprecation"))
+	require.Equal(t, "Sat, 17 Apr 2027 00:00:00 GMT", rec.Header().Get("Sunset"))
+	require.Equal(t, `</v1/echo>; rel="successor-version"`, rec.Header().Get("Link"))
+
+	rec = get("/items/42")
+	require.Equal(t, "42", rec.Body.String())
+	require.Equal(t, `</v1/items/42>; rel="successor-version"`, rec.Header().Get("Link"))
+
+	require.Equal(t, deprecations{"unversioned GET /echo", "unversioned GET /items/{id}"}, observed)
+}
+
+func TestUnversioned(t *testing.T) {
+	prefixes := Prefixes(Version{Prefix: "/v1"}, Version{}, Version{Prefix: "/v1beta"})
+	require.Equal(t, []string{"/v1beta", "/v1"}, prefixes)
+
+	for path, want := range map[string]string{
+		"/v1/echo":     "/echo",
+		"/v1beta/echo": "/echo",
+		"/echo":        "/echo",
+		"/v1":          "/v1",
+		"/v10/echo":    "/v10/echo",
+	} {
+		require.Equal(t, want, Unversioned(path, prefixes), path)
+	}
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/netutil"
+	"github.com/paulcapestany/toy-service/internal/problem"
+)
+
+// serve runs req through Require(p) wrapping a handler that echoes the body.
+func serve(t *testing.T, p Policy, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	h := Require(p)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	require.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+	var body problem.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, rec.Code, body.Status)
+	return body.Detail
+}
+
+func TestRequire_FailsClosedWithoutConfiguration(t *testing.T) {
+	t.Log("Test that a policy with no credentials and no networks rejects every request")
+
+	rec := serve(t, Policy{}, httptest.NewRequest(http.MethodGet, "/internal/config", nil))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.Contains(t, decodeError(t, rec), "not enabled")
+}
+
+func TestRequire_BearerToken(t *testing.T) {
+	p := Policy{Tokens: []string{"first", "second"}}
+
+	req := httptest.NewRequest(http.MethodGet, "/internal/config", nil)
+	rec := serve(t, p, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+	require.Equal(t, "unauthorized: missing credentials", decodeError(t, rec))
+
+	req = httptest.NewRequest(http.MethodGet, "/internal/config", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec = serve(t, p, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, "unauthorized: invalid token", decodeError(t, rec))
+
+	req = httptest.NewRequest(http.MethodGet, "/internal/config", nil)
+	req.Header.Set("Authorization", "bearer second")
+	require.Equal(t, http.StatusOK, serve(t, p, req).Code)
+}
+
+func TestRequire_EmptyBearerToken(t *testing.T) {
+	t.Log("Test that a bearer header without a token never matches, even against an empty configured token")
+
+	p := Policy{Tokens: []string{""}}
+	for _, header := range []string{"Bearer ", "Bearer   ", "bearer \t"} {
+		req := httptest.NewRequest(http.MethodPost, "/-/reload", nil)
+		req.RemoteAddr = "203.0.113.7:5555"
+		req.Header.Set("Authorization", header)
+		rec := serve(t, p, req)
+		require.Equal(t, http.StatusUnauthorized, rec.Code, "header %q", header)
+		require.Equal(t, "unauthorized: missing credentials", decodeError(t, rec))
+	}
+}
+
+func TestRequire_Networks(t *testing.T) {
+	t.Log("Test that the peer address is checked and X-Forwarded-For is ignored")
+
+	p := Policy{Networks: netutil.Loopback()}
+
+	req := httptest.NewRequest(http.MethodGet, "/internal/config", nil)
+	req.RemoteAddr = "127.0.0.1:5555"
+	require.Equal(t, http.StatusOK, serve(t, p, req).Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/internal/config", nil)
+	req.RemoteAddr = "203.0.113.7:5555"
+	req.Header.Set("X-Forwarded-For", "127.0.0.1")
+	rec := serve(t, p, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.Contains(t, decodeError(t, rec), "source network")
+}
+
+func TestRequire_NetworksAndToken(t *testing.T) {
+	t.Log("Test that an allowed network still needs a credential when one is configured")
+
+	p := Policy{Networks: netutil.Loopback(), Tokens: []string{"s3cret"}}
+
+	req := httptest.NewRequest(http.MethodGet, "/internal/config", nil)
+	req.RemoteAddr = "127.0.0.1:5555"
+	require.Equal(t, http.StatusUnauthorized, serve(t, p, req).Code)
+
+	req.Header.Set("Authorization", "Bearer s3cret")
+	require.Equal(t, http.StatusOK, serve(t, p, req).Code)
+}
+
+func TestPoliciesFromConfig(t *testing.T) {
+	t.Log("Test that unconfigured reload/internal policies default to loopback-only and admin is disabled")
+	p, err := PoliciesFromConfig(config.Auth{})
+	require.NoError(t, err)
+	require.Equal(t, netutil.Loopback(), p.Reload.Networks)
+	require.Equal(t, netutil.Loopback(), p.Internal.Networks)
+	require.Equal(t, DefaultReplayWindow, p.Reload.ReplayWindow)
+	require.False(t, p.Admin.hasCredentials())
+	require.Empty(t, p.Admin.Networks)
+
+	t.Log("Test that credentials lift the loopback default and the admin token is accepted everywhere")
+	cfg := config.Auth{
+		AdminToken:              "admin",
+		ReloadTokens:            []string{"a", "b"},
+		ReloadHMACSecret:        "hook",
+		ReloadHMACWindow:        30 * time.Second,
+		InternalAllowedNetworks: []string{"10.0.0.0/8"},
+	}
+	p, err = PoliciesFromConfig(cfg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "admin"}, p.Reload.Tokens)
+	require.Equal(t, []string{"a", "b"}, cfg.ReloadTokens, "the config is not modified")
+	require.Equal(t, []byte("hook"), p.Reload.HMACSecret)
+	require.Equal(t, "30s", p.Reload.ReplayWindow.String())
+	require.Empty(t, p.Reload.Networks)
+	require.Equal(t, []string{"admin"}, p.Internal.Tokens)
+	require.Len(t, p.Internal.Networks, 1)
+	require.Equal(t, []string{"admin"}, p.Admin.Tokens)
+
+	cfg.InternalAllowedNetworks = []string{"*"}
+	p, err = PoliciesFromConfig(cfg)
+	require.NoError(t, err)
+	require.Empty(t, p.Internal.Networks)
+
+	_, err = PoliciesFromConfig(config.Auth{ReloadAllowedNetworks: []string{"nope"}})
+	require.ErrorContains(t, err, "reload allowed networks")
+}
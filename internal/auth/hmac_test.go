@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func signedRequest(secret string, ts time.Time, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/-/reload", strings.NewReader(body))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign([]byte(secret), ts.Unix(), http.MethodPost, "/-/reload", []byte(body)))
+	return req
+}
+
+func TestRequire_HMAC(t *testing.T) {
+	p := Policy{HMACSecret: []byte("hook"), ReplayWindow: time.Minute}
+	handler := Require(p)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Log("Test that a valid signature is accepted and the body reaches the handler")
+	now := time.Now()
+	rec := serve(signedRequest("hook", now, `{"reason":"rotated"}`))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, `{"reason":"rotated"}`, rec.Body.String())
+
+	t.Log("Test that replaying the same signed request is rejected")
+	rec = serve(signedRequest("hook", now, `{"reason":"rotated"}`))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, "unauthorized: signature already used", decodeError(t, rec))
+
+	t.Log("Test that a wrong secret or tampered body is rejected")
+	rec = serve(signedRequest("other", now, ""))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, "unauthorized: invalid signature", decodeError(t, rec))
+
+	req := signedRequest("hook", now, "original")
+	req.Body = io.NopCloser(strings.NewReader("tampered"))
+	require.Equal(t, http.StatusUnauthorized, serve(req).Code)
+
+	t.Log("Test that timestamps outside the replay window are rejected")
+	rec = serve(signedRequest("hook", now.Add(-2*time.Minute), ""))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, "unauthorized: timestamp outside replay window", decodeError(t, rec))
+	require.Equal(t, http.StatusUnauthorized, serve(signedRequest("hook", now.Add(2*time.Minute), "")).Code)
+}
+
+func TestReplayCache_Expires(t *testing.T) {
+	c := newReplayCache()
+	now := time.Unix(1000, 0)
+	require.True(t, c.add("sig", now.Add(time.Minute), now))
+	require.False(t, c.add("sig", now.Add(time.Minute), now))
+	require.True(t, c.add("sig", now.Add(3*time.Minute), now.Add(2*time.Minute)))
+	require.Len(t, c.seen, 1)
+}
@@ -0,0 +1,98 @@
+// policies.go
+//
+// Builds the policies for operational routes from the auth configuration.
+
+package auth
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/netutil"
+)
+
+// Policies groups the policies applied to the operational routes.
+type Policies struct {
+	// Reload protects POST /-/reload.
+	Reload Policy
+	// Internal protects every route under /internal/.
+	Internal Policy
+	// Admin protects runtime administration such as /-/log-level.
+	Admin Policy
+}
+
+// PoliciesFromConfig builds the policies from the auth section:
+//
+//   - AdminToken: accepted by every policy; the only credential for Admin.
+//   - ReloadTokens: bearer tokens for /-/reload.
+//   - ReloadHMACSecret / ReloadHMACWindow: HMAC-signed webhooks for /-/reload.
+//   - ReloadAllowedNetworks: source networks allowed to call /-/reload.
+//   - InternalTokens: bearer tokens for /internal/*.
+//   - InternalAllowedNetworks: source networks allowed to call /internal/*.
+//
+// Network lists accept IPs and CIDRs; "*" disables the network restriction.
+// When neither credentials nor networks are configured for Reload or
+// Internal, they default to loopback-only so same-pod callers keep working.
+func PoliciesFromConfig(cfg config.Auth) (Policies, error) {
+	admin := splitList(cfg.AdminToken)
+
+	window := cfg.ReloadHMACWindow
+	if window == 0 {
+		window = DefaultReplayWindow
+	}
+
+	reload := Policy{
+		Name:         "reload",
+		Tokens:       append(append([]string(nil), cfg.ReloadTokens...), admin...),
+		ReplayWindow: window,
+	}
+	if cfg.ReloadHMACSecret != "" {
+		reload.HMACSecret = []byte(cfg.ReloadHMACSecret)
+	}
+	var err error
+	if reload.Networks, err = allowedNetworks(cfg.ReloadAllowedNetworks, reload.hasCredentials()); err != nil {
+		return Policies{}, fmt.Errorf("invalid reload allowed networks: %w", err)
+	}
+
+	internal := Policy{
+		Name:   "internal",
+		Tokens: append(append([]string(nil), cfg.InternalTokens...), admin...),
+	}
+	if internal.Networks, err = allowedNetworks(cfg.InternalAllowedNetworks, internal.hasCredentials()); err != nil {
+		return Policies{}, fmt.Errorf("invalid internal allowed networks: %w", err)
+	}
+
+	return Policies{
+		Reload:   reload,
+		Internal: internal,
+		Admin:    Policy{Name: "admin", Tokens: admin},
+	}, nil
+}
+
+// allowedNetworks parses a network list. An empty list means loopback-only
+// unless credentials are configured, and "*" means anywhere.
+func allowedNetworks(list []string, hasCredentials bool) ([]*net.IPNet, error) {
+	switch {
+	case len(list) == 0:
+		if hasCredentials {
+			return nil, nil
+		}
+		return netutil.Loopback(), nil
+	case len(list) == 1 && list[0] == "*":
+		return nil, nil
+	}
+	return netutil.ParseNetworks(strings.Join(list, ","))
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+// bodylimit.go
+//
+// Caps request body sizes per path. Bodies are wrapped in
+// http.MaxBytesReader, so handlers see an *http.MaxBytesError (carrying the
+// limit) once a client sends too much and can answer 413 themselves.
+
+package bodylimit
+
+import (
+	"net/http"
+
+	"github.com/paulcapestany/toy-service/internal/apiversion"
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+// Options configures the body limit middleware.
+type Options struct {
+	// Default applies to every path without an entry in Paths.
+	Default int64
+	// Paths maps exact request paths (e.g. "/echo") to their own limit.
+	Paths map[string]int64
+	// Prefixes are the API version prefixes (e.g. "/v1"). A path under one
+	// without its own entry gets the limit of its unversioned path.
+	Prefixes []string
+}
+
+// OptionsFromConfig builds Options from the limits configuration.
+func OptionsFromConfig(cfg config.Limits) Options {
+	opts := Options{Default: int64(cfg.MaxBodyBytes), Paths: make(map[string]int64, len(cfg.BodyLimits))}
+	for path, size := range cfg.BodyLimits {
+		opts.Paths[path] = int64(size)
+	}
+	return opts
+}
+
+// Limit returns the body limit for path.
+func (o Options) Limit(path string) int64 {
+	if n, ok := o.Paths[path]; ok {
+		return n
+	}
+	if n, ok := o.Paths[apiversion.Unversioned(path, o.Prefixes)]; ok {
+		return n
+	}
+	return o.Default
+}
+
+// New returns middleware enforcing opts. A non-positive limit leaves the
+// body unrestricted.
+func New(opts Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if n := opts.Limit(r.URL.Path); n > 0 && r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, n)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
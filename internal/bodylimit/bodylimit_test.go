@@ -0,0 +1,59 @@
+package bodylimit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+func TestOptionsFromConfig(t *testing.T) {
+	opts := OptionsFromConfig(config.Limits{MaxBodyBytes: config.MiB, BodyLimits: config.BodyLimits{"/small": 8}})
+	require.Equal(t, int64(1<<20), opts.Limit("/echo"))
+	require.Equal(t, int64(8), opts.Limit("/small"))
+}
+
+func TestNew(t *testing.T) {
+	t.Log("Test that bodies over the path's limit fail with a MaxBytesError carrying the limit")
+
+	h := New(Options{Default: 16, Paths: map[string]int64{"/small": 4}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			_, _ = io.WriteString(w, tooLarge.Error())
+			return
+		}
+		require.NoError(t, err)
+	}))
+
+	for _, tc := range []struct {
+		path, body string
+		want       int
+	}{
+		{"/echo", strings.Repeat("a", 16), http.StatusOK},
+		{"/echo", strings.Repeat("a", 17), http.StatusRequestEntityTooLarge},
+		{"/small", "abcd", http.StatusOK},
+		{"/small", "abcde", http.StatusRequestEntityTooLarge},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body)))
+		require.Equal(t, tc.want, w.Code, "%s with %d bytes", tc.path, len(tc.body))
+	}
+}
+
+func TestLimit_VersionedPaths(t *testing.T) {
+	t.Log("Test that a limit for /echo also applies to /v1/echo unless it has its own")
+
+	opts := Options{Default: 16, Paths: map[string]int64{"/small": 4, "/v1/own": 8, "/own": 2}, Prefixes: []string{"/v1"}}
+	require.Equal(t, int64(4), opts.Limit("/v1/small"))
+	require.Equal(t, int64(8), opts.Limit("/v1/own"))
+	require.Equal(t, int64(2), opts.Limit("/own"))
+	require.Equal(t, int64(16), opts.Limit("/v1small"))
+}
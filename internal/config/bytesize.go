@@ -0,0 +1,117 @@
+// bytesize.go
+//
+// Human-readable byte sizes ("1MiB", "64KiB", "1048576") for limits set in
+// the config file, environment or flags, and per-route body limits.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes. It parses and prints binary units (KiB,
+// MiB, GiB), accepts decimal units (KB, MB, GB) and bare byte counts.
+type ByteSize int64
+
+// Binary size units.
+const (
+	KiB ByteSize = 1 << (10 * (iota + 1))
+	MiB
+	GiB
+)
+
+var byteUnits = []struct {
+	suffix string
+	size   ByteSize
+}{
+	{"GiB", GiB}, {"MiB", MiB}, {"KiB", KiB},
+	{"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3},
+	{"B", 1},
+}
+
+// ParseByteSize parses s, e.g. "1MiB", "512 KiB", "10MB" or "4096".
+func ParseByteSize(s string) (ByteSize, error) {
+	raw := strings.TrimSpace(s)
+	mult := ByteSize(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(strings.ToUpper(raw), strings.ToUpper(u.suffix)) {
+			raw, mult = strings.TrimSpace(raw[:len(raw)-len(u.suffix)]), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n < 0 || ByteSize(n) > (1<<63-1)/mult {
+		return 0, fmt.Errorf("invalid byte size %q (want e.g. 1MiB, 64KiB or 4096)", s)
+	}
+	return ByteSize(n) * mult, nil
+}
+
+// String formats b in the largest binary unit that divides it exactly.
+func (b ByteSize) String() string {
+	for _, u := range byteUnits[:3] {
+		if b != 0 && b%u.size == 0 {
+			return strconv.FormatInt(int64(b/u.size), 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	v, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// BodyLimits maps request paths (e.g. "/echo") to their maximum body size.
+// In the environment and on the command line it is written as
+// "/echo=64KiB,/upload=10MiB".
+type BodyLimits map[string]ByteSize
+
+// Paths returns the configured paths in sorted order.
+func (l BodyLimits) Paths() []string {
+	paths := make([]string, 0, len(l))
+	for path := range l {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// String formats l as a comma-separated list sorted by path.
+func (l BodyLimits) String() string {
+	paths := l.Paths()
+	for i, path := range paths {
+		paths[i] = path + "=" + l[path].String()
+	}
+	return strings.Join(paths, ",")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (l *BodyLimits) UnmarshalText(text []byte) error {
+	out := BodyLimits{}
+	for _, entry := range splitList(string(text)) {
+		path, size, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("invalid body limit %q (want path=size)", entry)
+		}
+		v, err := ParseByteSize(size)
+		if err != nil {
+			return err
+		}
+		out[strings.TrimSpace(path)] = v
+	}
+	*l = out
+	return nil
+}
@@ -0,0 +1,43 @@
+// config.go
+//
+// Defines the runtime configuration snapshot derived from the loaded Config
+// (see settings.go). A Snapshot is immutable once published through a Store;
+// changes (e.g. secret reloads) produce a new Snapshot with a higher
+// generation number.
+
+package config
+
+// DefaultVersion is reported when VERSION is not configured.
+const DefaultVersion = "v0.28.0"
+
+// DefaultSecretDir is where the Helm chart mounts the backend Secret.
+const DefaultSecretDir = "/etc/backend-secret"
+
+// FakeSecretName is the name of the demo secret surfaced by /info and /internal/config.
+const FakeSecretName = "FAKE_SECRET"
+
+// Snapshot is an immutable view of the service configuration.
+// Never modify a Snapshot obtained from a Store; use Store.Update instead.
+type Snapshot struct {
+	Name      string
+	Env       string
+	Version   string
+	GitCommit string
+
+	// SecretDir is the directory holding file-mounted secrets.
+	SecretDir string
+	// SecretSchema declares the secrets expected in SecretDir
+	// (see secrets.ParseSchema); "" means just FAKE_SECRET.
+	SecretSchema string
+	// Secrets maps secret names (e.g. FAKE_SECRET) to their current values.
+	// Reloads replace the map wholesale; it must never be mutated in place.
+	Secrets map[string]string
+
+	// Generation increases by one every time a new snapshot is published.
+	Generation uint64
+}
+
+// Secret returns the value of the named secret, or "" when it is not set.
+func (s *Snapshot) Secret(name string) string {
+	return s.Secrets[name]
+}
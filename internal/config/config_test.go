@@ -0,0 +1,353 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// env returns a lookup function over a fixed environment.
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	t.Log("Test that Load applies defaults when nothing is configured")
+
+	cfg, flags, err := Load(nil, env(nil))
+	require.NoError(t, err)
+	require.False(t, flags.PrintConfig)
+
+	snap := cfg.Snapshot()
+	require.Equal(t, "toy-service", snap.Name)
+	require.Equal(t, "dev", snap.Env)
+	require.Equal(t, "info", cfg.Service.LogVerbosity)
+	require.Equal(t, DefaultVersion, snap.Version)
+	require.Equal(t, "unknown", snap.GitCommit)
+	require.Equal(t, DefaultSecretDir, snap.SecretDir)
+	require.Empty(t, snap.Secret(FakeSecretName))
+	require.Equal(t, ":8080", cfg.Server.Addr())
+	require.Equal(t, "127.0.0.1:8081", cfg.Server.AdminAddr())
+}
+
+func TestLoad_Env(t *testing.T) {
+	t.Log("Test that environment variables override defaults")
+
+	cfg, _, err := Load(nil, env(map[string]string{
+		"SERVICE_ENV":      "prod",
+		"SECRET_FILE_DIR":  "/tmp/secret",
+		"FAKE_SECRET":      "topsecret",
+		"PORT":             " :7070 ",
+		"ADMIN_HOST":       "::1",
+		"RELOAD_TOKENS":    "a, b,",
+		"SHUTDOWN_TIMEOUT": "30s",
+		"H2C":              "true",
+		"LOG_VERBOSITY":    "",
+	}))
+	require.NoError(t, err)
+
+	snap := cfg.Snapshot()
+	require.Equal(t, "prod", snap.Env)
+	require.Equal(t, "info", cfg.Service.LogVerbosity, "empty variables are ignored")
+	require.Equal(t, "/tmp/secret", snap.SecretDir)
+	require.Equal(t, "topsecret", snap.Secret(FakeSecretName))
+	require.Equal(t, ":7070", cfg.Server.Addr())
+	require.Equal(t, "[::1]:8081", cfg.Server.AdminAddr())
+	require.Equal(t, []string{"a", "b"}, cfg.Auth.ReloadTokens)
+	require.Equal(t, 30*time.Second, cfg.Shutdown.Timeout)
+	require.True(t, cfg.Server.H2C)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	t.Log("Test that flags override env, which overrides the file, which overrides defaults")
+
+	path := writeFile(t, "config.yaml", `
+service:
+  env: staging
+  logVerbosity: debug
+server:
+  port: 9000
+  adminPort: 9001
+shutdown:
+  delay: 2s
+`)
+	cfg, flags, err := Load(
+		[]string{"--config", path, "--port=9100", "--h2c"},
+		env(map[string]string{"SERVICE_ENV": "prod", "PORT": "9050"}),
+	)
+	require.NoError(t, err)
+	require.Equal(t, path, flags.ConfigFile)
+	require.Equal(t, "prod", cfg.Service.Env)
+	require.Equal(t, "debug", cfg.Service.LogVerbosity)
+	require.Equal(t, 9100, cfg.Server.Port)
+	require.Equal(t, 9001, cfg.Server.AdminPort)
+	require.Equal(t, 2*time.Second, cfg.Shutdown.Delay)
+	require.Equal(t, 5*time.Second, cfg.Shutdown.Timeout)
+	require.True(t, cfg.Server.H2C)
+}
+
+func TestLoad_JSONFileFromEnv(t *testing.T) {
+	t.Log("Test that CONFIG_FILE selects a JSON config file")
+
+	path := writeFile(t, "config.json", `{"service": {"version": "v9.9.9"}, "auth": {"internalTokens": ["t1"]}}`)
+	cfg, flags, err := Load(nil, env(map[string]string{ConfigFileEnv: path}))
+	require.NoError(t, err)
+	require.Equal(t, path, flags.ConfigFile)
+	require.Equal(t, "v9.9.9", cfg.Service.Version)
+	require.Equal(t, []string{"t1"}, cfg.Auth.InternalTokens)
+}
+
+func TestLoad_FileErrors(t *testing.T) {
+	t.Log("Test that unreadable files and unknown keys are rejected")
+
+	_, _, err := Load([]string{"--config", filepath.Join(t.TempDir(), "missing.yaml")}, env(nil))
+	require.Error(t, err)
+
+	path := writeFile(t, "config.yaml", "server:\n  prot: 9000\n")
+	_, _, err = Load([]string{"--config", path}, env(nil))
+	require.ErrorContains(t, err, "prot")
+}
+
+func TestLoad_Validation(t *testing.T) {
+	t.Log("Test that every invalid field is reported with its value and source")
+
+	path := writeFile(t, "config.yaml", "tls:\n  clientAuth: sometimes\n")
+	_, _, err := Load(
+		[]string{"--config", path, "--shutdown-timeout=0s"},
+		env(map[string]string{
+			"PORT":                      "70000",
+			"ADMIN_PORT":                "abc",
+			"LOG_VERBOSITY":             "loud",
+			"INTERNAL_ALLOWED_NETWORKS": "not-an-ip",
+		}),
+		Validator{Field: "secrets.schema", Check: func(*Config) error { return errors.New("bad schema") }},
+	)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.ElementsMatch(t, []string{
+		`server.adminPort: invalid integer (got "abc" from env ADMIN_PORT)`,
+		`service.logVerbosity: must be one of trace, debug, info, warn, error (got "loud" from env LOG_VERBOSITY)`,
+		`server.port: must be between 1 and 65535 (got "70000" from env PORT)`,
+		`auth.internalAllowedNetworks: invalid IP address "not-an-ip" (got "not-an-ip" from env INTERNAL_ALLOWED_NETWORKS)`,
+		`tls.clientAuth: must be require or optional (got "sometimes" from file ` + path + `)`,
+		`shutdown.timeout: must be positive (got "0s" from flag --shutdown-timeout)`,
+		`secrets.schema: bad schema`,
+	}, verr.Messages())
+}
+
+func TestLoad_BlankTokens(t *testing.T) {
+	t.Log("Test that empty or whitespace-only auth tokens are rejected without echoing them")
+
+	path := writeFile(t, "config.yaml", "auth:\n  adminToken: \"  \"\n  reloadTokens: [\"\"]\n  internalTokens: [ok, \" \"]\n")
+	_, _, err := Load([]string{"--config", path}, env(nil))
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.ElementsMatch(t, []string{
+		`auth.adminToken: must not be blank (got [redacted] from file ` + path + `)`,
+		`auth.reloadTokens: must not contain empty tokens (got [redacted] from file ` + path + `)`,
+		`auth.internalTokens: must not contain empty tokens (got [redacted] from file ` + path + `)`,
+	}, verr.Messages())
+}
+
+func TestLoad_CrossFieldValidation(t *testing.T) {
+	t.Log("Test that conflicting settings are rejected")
+
+	for name, vars := range map[string]map[string]string{
+		"adminPortCollision": {"PORT": "8080", "ADMIN_PORT": "8080"},
+		"certWithoutKey":     {"TLS_CERT_FILE": "cert.pem"},
+		"caWithoutCert":      {"TLS_CLIENT_CA_FILE": "ca.pem"},
+		"h2cWithTLS":         {"TLS_CERT_FILE": "cert.pem", "TLS_KEY_FILE": "key.pem", "H2C": "true"},
+		"badSocketMode":      {"LISTEN_UNIX_SOCKET_MODE": "999"},
+		"badExporter":        {"OTEL_TRACES_EXPORTER": "zipkin"},
+		"badValidationMode":  {"OPENAPI_VALIDATION": "strict"},
+		"relativeServerURL":  {"OPENAPI_SERVER_URL": "/api"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := Load(nil, env(vars))
+			var verr *ValidationError
+			require.ErrorAs(t, err, &verr)
+		})
+	}
+
+	t.Run("unixSocketAllowsSamePort", func(t *testing.T) {
+		_, _, err := Load(nil, env(map[string]string{"LISTEN_UNIX_SOCKET": "/tmp/toy.sock", "ADMIN_PORT": "8080"}))
+		require.NoError(t, err)
+	})
+}
+
+func TestLoad_Flags(t *testing.T) {
+	t.Log("Test that help, unknown flags and stray arguments are reported")
+
+	_, _, err := Load([]string{"-h"}, env(nil))
+	require.ErrorIs(t, err, flag.ErrHelp)
+
+	_, _, err = Load([]string{"--no-such-flag"}, env(nil))
+	require.Error(t, err)
+
+	_, _, err = Load([]string{"serve"}, env(nil))
+	require.Error(t, err)
+
+	_, flags, err := Load([]string{"--print-config"}, env(nil))
+	require.NoError(t, err)
+	require.True(t, flags.PrintConfig)
+
+	var buf bytes.Buffer
+	Usage(&buf)
+	require.Contains(t, buf.String(), "-port")
+	require.NotContains(t, buf.String(), "admin-token", "secrets have no flags")
+}
+
+func TestPrint(t *testing.T) {
+	t.Log("Test that printed configuration redacts secrets and loads back")
+
+	cfg, _, err := Load(nil, env(map[string]string{
+		"FAKE_SECRET":   "topsecret",
+		"ADMIN_TOKEN":   "admintoken",
+		"RELOAD_TOKENS": "r1,r2",
+		"SERVICE_ENV":   "prod",
+	}))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, Print(&buf, cfg))
+	out := buf.String()
+	require.NotContains(t, out, "topsecret")
+	require.NotContains(t, out, "admintoken")
+	require.NotContains(t, out, "r1")
+	require.Contains(t, out, "fakeSecret: '[redacted]'")
+	require.Contains(t, out, "internalTokens: []")
+	require.Contains(t, out, "timeout: 5s")
+	require.Equal(t, "topsecret", cfg.Secrets.FakeSecret, "the original is untouched")
+
+	loaded, _, err := Load([]string{"--config", writeFile(t, "printed.yaml", out)}, env(nil))
+	require.NoError(t, err)
+	require.Equal(t, "prod", loaded.Service.Env)
+	require.Equal(t, cfg.Shutdown, loaded.Shutdown)
+}
+
+func TestByteSize(t *testing.T) {
+	t.Log("Test that byte sizes parse common units and print in binary units")
+
+	for raw, want := range map[string]ByteSize{
+		"4096":    4096,
+		"1MiB":    MiB,
+		"512 kib": 512 * KiB,
+		"2GiB":    2 * GiB,
+		"10MB":    10_000_000,
+		"100B":    100,
+	} {
+		got, err := ParseByteSize(raw)
+		require.NoError(t, err, raw)
+		require.Equal(t, want, got, raw)
+	}
+	for _, raw := range []string{"", "lots", "-1", "1.5MiB", "9999999999GiB"} {
+		_, err := ParseByteSize(raw)
+		require.Error(t, err, raw)
+	}
+
+	require.Equal(t, "1MiB", MiB.String())
+	require.Equal(t, "1536KiB", (MiB + 512*KiB).String())
+	require.Equal(t, "1000B", ByteSize(1000).String())
+	require.Equal(t, "0B", ByteSize(0).String())
+}
+
+func TestLoad_Limits(t *testing.T) {
+	t.Log("Test that limits load from every layer and are validated")
+
+	path := writeFile(t, "config.yaml", `
+server:
+  writeTimeout: 30s
+limits:
+  maxHeaderBytes: 64KiB
+  bodyLimits:
+    /echo: 16KiB
+    /upload: 10MiB
+`)
+	cfg, _, err := Load([]string{"--config", path, "--max-connections=100"}, env(map[string]string{"MAX_BODY_BYTES": "2MiB"}))
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, cfg.Server.WriteTimeout)
+	require.Equal(t, 5*time.Second, cfg.Server.ReadHeaderTimeout)
+	require.Equal(t, 64*KiB, cfg.Limits.MaxHeaderBytes)
+	require.Equal(t, 100, cfg.Limits.MaxConnections)
+	require.Equal(t, 16*KiB, cfg.Limits.BodyLimit("/echo"))
+	require.Equal(t, 2*MiB, cfg.Limits.BodyLimit("/info"))
+
+	var buf bytes.Buffer
+	require.NoError(t, Print(&buf, cfg))
+	require.Contains(t, buf.String(), "maxHeaderBytes: 64KiB")
+	require.Contains(t, buf.String(), "/echo: 16KiB")
+
+	cfg, _, err = Load(nil, env(map[string]string{"BODY_LIMITS": "/echo=1KiB, /big=1GiB"}))
+	require.NoError(t, err)
+	require.Equal(t, BodyLimits{"/echo": KiB, "/big": GiB}, cfg.Limits.BodyLimits)
+
+	_, _, err = Load(nil, env(map[string]string{
+		"BODY_LIMITS":     "echo=1KiB",
+		"MAX_BODY_BYTES":  "0",
+		"IDLE_TIMEOUT":    "-1s",
+		"MAX_CONNECTIONS": "-5",
+	}))
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Len(t, verr.Problems, 4)
+
+	_, _, err = Load(nil, env(map[string]string{"MAX_HEADER_BYTES": "huge"}))
+	require.ErrorContains(t, err, `limits.maxHeaderBytes: invalid byte size "huge"`)
+}
+
+func TestLoad_CORS(t *testing.T) {
+	t.Log("Test that CORS policies load per environment and invalid policies are rejected")
+
+	path := writeFile(t, "config.yaml", `
+cors:
+  environments:
+    prod:
+      allowedOrigins: [https://app.example.com, https://*.example.org]
+      allowCredentials: true
+  routes:
+    /echo:
+      allowedMethods: [POST]
+`)
+	cfg, _, err := Load([]string{"--config", path}, env(map[string]string{"SERVICE_ENV": "prod"}))
+	require.NoError(t, err)
+	prod := cfg.CORS.Policy("prod")
+	require.Equal(t, []string{"https://app.example.com", "https://*.example.org"}, prod.AllowedOrigins)
+	require.True(t, prod.Credentials())
+	require.Equal(t, []string{"POST"}, cfg.CORS.RoutePolicies("prod")["/echo"].AllowedMethods)
+	require.Equal(t, []string{"*"}, cfg.CORS.Policy("dev").AllowedOrigins)
+	require.Equal(t, []string{"*"}, cfg.CORS.Policy("staging").AllowedOrigins, "unconfigured environments allow any origin")
+
+	cfg, _, err = Load(nil, env(map[string]string{"CORS_ALLOWED_ORIGINS": "https://a.example.com,https://b.example.com"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.CORS.Policy("dev").AllowedOrigins)
+
+	for name, vars := range map[string]map[string]string{
+		"wildcardWithCredentials": {"CORS_ALLOWED_ORIGINS": "*", "CORS_ALLOW_CREDENTIALS": "true"},
+		"missingScheme":           {"CORS_ALLOWED_ORIGINS": "app.example.com"},
+		"innerWildcard":           {"CORS_ALLOWED_ORIGINS": "https://app.*.com"},
+		"lowerCaseMethod":         {"CORS_ALLOWED_METHODS": "get"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := Load(nil, env(vars))
+			require.ErrorContains(t, err, "cors.")
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// cors.go
+//
+// Resolves the CORS policy for a service environment and request path from
+// the layered settings in the CORS section.
+
+package config
+
+import "strings"
+
+// Policy returns the top-level CORS policy overlaid with the entry for env,
+// if any. An empty origin list allows any origin, as the service always has,
+// so front-ends keep working until their origins are configured; set
+// Disabled to turn CORS off.
+func (c CORS) Policy(env string) CORSPolicy {
+	credentials := c.AllowCredentials
+	p := CORSPolicy{
+		AllowedOrigins:   c.AllowedOrigins,
+		AllowedMethods:   c.AllowedMethods,
+		AllowedHeaders:   c.AllowedHeaders,
+		ExposedHeaders:   c.ExposedHeaders,
+		AllowCredentials: &credentials,
+		MaxAge:           c.MaxAge,
+	}
+	if o, ok := c.Environments[env]; ok {
+		p = p.Overlay(o)
+	}
+	if len(p.AllowedOrigins) == 0 && !p.Disabled {
+		p.AllowedOrigins = []string{"*"}
+	}
+	return p
+}
+
+// RoutePolicies returns the policy for each configured route prefix in env.
+func (c CORS) RoutePolicies(env string) map[string]CORSPolicy {
+	base := c.Policy(env)
+	out := make(map[string]CORSPolicy, len(c.Routes))
+	for prefix, o := range c.Routes {
+		out[prefix] = base.Overlay(o)
+	}
+	return out
+}
+
+// Overlay returns p with every field set in o replacing p's.
+func (p CORSPolicy) Overlay(o CORSPolicy) CORSPolicy {
+	if o.Disabled {
+		return CORSPolicy{Disabled: true}
+	}
+	if len(o.AllowedOrigins) > 0 {
+		p.AllowedOrigins = o.AllowedOrigins
+	}
+	if len(o.AllowedMethods) > 0 {
+		p.AllowedMethods = o.AllowedMethods
+	}
+	if len(o.AllowedHeaders) > 0 {
+		p.AllowedHeaders = o.AllowedHeaders
+	}
+	if len(o.ExposedHeaders) > 0 {
+		p.ExposedHeaders = o.ExposedHeaders
+	}
+	if o.AllowCredentials != nil {
+		p.AllowCredentials = o.AllowCredentials
+	}
+	if o.MaxAge != 0 {
+		p.MaxAge = o.MaxAge
+	}
+	return p
+}
+
+// Enabled reports whether p allows any cross-origin requests.
+func (p CORSPolicy) Enabled() bool {
+	return !p.Disabled && len(p.AllowedOrigins) > 0
+}
+
+// AnyOrigin reports whether p allows every origin.
+func (p CORSPolicy) AnyOrigin() bool {
+	for _, origin := range p.AllowedOrigins {
+		if origin == "*" {
+			return !p.Disabled
+		}
+	}
+	return false
+}
+
+// Credentials reports whether p allows credentialed requests.
+func (p CORSPolicy) Credentials() bool {
+	return p.AllowCredentials != nil && *p.AllowCredentials
+}
+
+// validateCORSPolicy returns a problem with p, or "".
+func validateCORSPolicy(p CORSPolicy) string {
+	for _, origin := range p.AllowedOrigins {
+		if origin == "*" {
+			if p.Credentials() {
+				return `"*" cannot be combined with allowCredentials; list the origins instead`
+			}
+			continue
+		}
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || (scheme != "http" && scheme != "https") || host == "" || strings.Contains(host, "/") {
+			return "origin " + origin + " must look like https://app.example.com"
+		}
+		if strings.Count(host, "*") > 1 || (strings.Contains(host, "*") && !strings.HasPrefix(host, "*.")) {
+			return "origin " + origin + " may only use a leading *. wildcard, e.g. https://*.example.com"
+		}
+	}
+	for _, method := range p.AllowedMethods {
+		if method == "" || strings.ToUpper(method) != method || strings.ContainsAny(method, " \t") {
+			return "method " + method + " must be an upper-case HTTP method"
+		}
+	}
+	if p.MaxAge < 0 {
+		return "maxAge must not be negative"
+	}
+	return ""
+}
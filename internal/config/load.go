@@ -0,0 +1,333 @@
+// load.go
+//
+// Builds a Config from defaults, an optional YAML or JSON file, environment
+// variables and command-line flags, in increasing order of precedence. The
+// origin of every value is remembered so validation errors can say where a
+// bad value came from.
+
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv names the environment variable that may point at a config
+// file when --config is not given.
+const ConfigFileEnv = "CONFIG_FILE"
+
+// Flags holds the command-line flags that control loading itself rather
+// than a Config field.
+type Flags struct {
+	// ConfigFile is the file named by --config or CONFIG_FILE, if any.
+	ConfigFile string
+	// PrintConfig asks for the effective configuration to be printed.
+	PrintConfig bool
+}
+
+// Validator is an additional check run by Load, used by packages that cannot
+// be imported here (e.g. secrets, which depends on config).
+type Validator struct {
+	// Field is the dotted path reported with the error, e.g. "secrets.schema".
+	Field string
+	Check func(*Config) error
+}
+
+// field describes one leaf of Config.
+type field struct {
+	path   string
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// fields lists the leaves of c in declaration order; values are addressable
+// so they can be set through reflection.
+func fields(c *Config) []field {
+	var out []field
+	root := reflect.ValueOf(c).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Type().Field(i)
+		sv := root.Field(i)
+		for j := 0; j < sv.NumField(); j++ {
+			f := sv.Type().Field(j)
+			out = append(out, field{
+				path:   section.Tag.Get("yaml") + "." + f.Tag.Get("yaml"),
+				env:    f.Tag.Get("env"),
+				flag:   f.Tag.Get("flag"),
+				usage:  f.Tag.Get("usage"),
+				secret: f.Tag.Get("secret") == "true",
+				value:  sv.Field(j),
+			})
+		}
+	}
+	return out
+}
+
+// Load builds the configuration from args (without the program name) and
+// the environment, then validates it. Validation problems are returned as a
+// *ValidationError alongside the (invalid) Config so it can still be printed.
+// flag.ErrHelp is returned when -h or --help is given.
+func Load(args []string, lookupEnv func(string) (string, bool), validators ...Validator) (Config, Flags, error) {
+	cfg := Default()
+	all := fields(&cfg)
+	sources := make(map[string]string, len(all))
+
+	var flags Flags
+	fs := newFlagSet(all, &flags)
+	if err := fs.Parse(args); err != nil {
+		return cfg, flags, err
+	}
+	if fs.NArg() > 0 {
+		return cfg, flags, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if flags.ConfigFile == "" {
+		if v, ok := lookupEnv(ConfigFileEnv); ok {
+			flags.ConfigFile = strings.TrimSpace(v)
+		}
+	}
+	if flags.ConfigFile != "" {
+		if err := loadFile(&cfg, flags.ConfigFile, sources); err != nil {
+			return cfg, flags, err
+		}
+	}
+
+	var problems []FieldError
+	set := func(f field, raw, source string) {
+		if err := setValue(f.value, raw); err != nil {
+			problems = append(problems, FieldError{Field: f.path, Message: err.Error(), Value: raw, Source: source, secret: f.secret})
+			return
+		}
+		sources[f.path] = source
+	}
+	for _, f := range all {
+		if f.env == "" {
+			continue
+		}
+		if v, ok := lookupEnv(f.env); ok && strings.TrimSpace(v) != "" {
+			set(f, v, "env "+f.env)
+		}
+	}
+	fs.Visit(func(fl *flag.Flag) {
+		if v, ok := fl.Value.(*fieldValue); ok {
+			set(v.field, v.raw, "flag --"+fl.Name)
+		}
+	})
+
+	for _, p := range validate(&cfg, validators) {
+		p.Source = sources[p.Field]
+		if p.Source == "" {
+			p.Source = "default"
+		}
+		for _, f := range all {
+			if f.path == p.Field {
+				p.Value, p.secret = display(f.value), f.secret
+			}
+		}
+		problems = append(problems, p)
+	}
+	if len(problems) > 0 {
+		return cfg, flags, &ValidationError{Problems: problems}
+	}
+	return cfg, flags, nil
+}
+
+// newFlagSet registers a flag for every field with a flag tag plus the
+// loader's own --config and --print-config.
+func newFlagSet(all []field, flags *Flags) *flag.FlagSet {
+	fs := flag.NewFlagSet("toy-service", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&flags.ConfigFile, "config", "", "YAML or JSON config file")
+	fs.BoolVar(&flags.PrintConfig, "print-config", false, "print the effective configuration with secrets redacted and exit")
+	for _, f := range all {
+		if f.flag == "" {
+			continue
+		}
+		fs.Var(&fieldValue{field: f}, f.flag, f.usage)
+	}
+	return fs
+}
+
+// Usage writes the flag help to w, including each flag's environment
+// variable and default.
+func Usage(w io.Writer) {
+	cfg := Default()
+	fs := newFlagSet(fields(&cfg), &Flags{})
+	fmt.Fprintf(w, "Usage of toy-service:\n")
+	fs.VisitAll(func(fl *flag.Flag) {
+		kind, notes := "", []string{}
+		if v, ok := fl.Value.(*fieldValue); ok {
+			if !v.IsBoolFlag() {
+				kind = " " + typeName(v.field.value)
+			}
+			notes = append(notes, "env "+v.field.env)
+		} else if fl.Name == "config" {
+			kind, notes = " path", []string{"env " + ConfigFileEnv}
+		}
+		if fl.DefValue != "" && fl.DefValue != "false" {
+			notes = append(notes, "default "+fl.DefValue)
+		}
+		fmt.Fprintf(w, "  --%s%s\n    \t%s", fl.Name, kind, fl.Usage)
+		if len(notes) > 0 {
+			fmt.Fprintf(w, " (%s)", strings.Join(notes, ", "))
+		}
+		fmt.Fprintln(w)
+	})
+}
+
+// typeName names the kind of value a flag expects.
+func typeName(v reflect.Value) string {
+	switch v.Interface().(type) {
+	case time.Duration:
+		return "duration"
+	case []string:
+		return "list"
+	case uint64:
+		return "uint"
+	case ByteSize:
+		return "size"
+	case BodyLimits:
+		return "list"
+	}
+	return v.Kind().String()
+}
+
+// fieldValue records a flag's raw value; it is converted after the file and
+// environment have been applied so that flags take precedence and parse
+// errors are reported together with every other problem.
+type fieldValue struct {
+	field field
+	raw   string
+}
+
+func (v *fieldValue) String() string {
+	if v == nil || !v.field.value.IsValid() {
+		return ""
+	}
+	return display(v.field.value)
+}
+
+func (v *fieldValue) Set(s string) error {
+	v.raw = s
+	return nil
+}
+
+// IsBoolFlag lets boolean fields be given as --h2c without a value.
+func (v *fieldValue) IsBoolFlag() bool {
+	return v.field.value.IsValid() && v.field.value.Kind() == reflect.Bool
+}
+
+// loadFile decodes a YAML or JSON file over cfg. Unknown keys are rejected so
+// typos do not go unnoticed.
+func loadFile(cfg *Config, path string, sources map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	// JSON is a subset of YAML, so one decoder handles both formats.
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		section := root.Content[i+1]
+		for j := 0; j+1 < len(section.Content); j += 2 {
+			sources[root.Content[i].Value+"."+section.Content[j].Value] = "file " + path
+		}
+	}
+	return nil
+}
+
+// setValue parses raw into v according to its type. Lists are
+// comma-separated and ports may carry a leading colon (":8080").
+func setValue(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+	switch v.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration")
+		}
+		v.SetInt(int64(d))
+		return nil
+	case []string:
+		v.Set(reflect.ValueOf(splitList(raw)))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean")
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimPrefix(raw, ":"))
+		if err != nil {
+			return fmt.Errorf("invalid integer")
+		}
+		v.SetInt(int64(n))
+	case reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer")
+		}
+		v.SetUint(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// display renders v the way it would be written in env or a flag.
+func display(v reflect.Value) string {
+	switch x := v.Interface().(type) {
+	case time.Duration:
+		return x.String()
+	case []string:
+		return strings.Join(x, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
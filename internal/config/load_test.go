@@ -0,0 +1,90 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_Addr(t *testing.T) {
+	t.Log("Test that PORT is parsed into the public listen address")
+
+	for name, tc := range map[string]struct {
+		port string
+		want string
+	}{
+		"defaultPort":       {"", ":8080"},
+		"numericPort":       {"9090", ":9090"},
+		"prefixedPort":      {":7070", ":7070"},
+		"trimmedWhitespace": {"  9800  ", ":9800"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, _, err := Load(nil, env(map[string]string{"PORT": tc.port}))
+			require.NoError(t, err)
+			require.Equal(t, tc.want, cfg.Server.Addr())
+		})
+	}
+
+	t.Log("Test that a bad PORT is rejected rather than falling back to :8080")
+	for name, tc := range map[string]struct {
+		port string
+		want string
+	}{
+		"missingPortNumber": {":", `server.port: invalid integer (got ":" from env PORT)`},
+		"invalidPort":       {"abc", `server.port: invalid integer (got "abc" from env PORT)`},
+		"outOfRangePort":    {"70000", `server.port: must be between 1 and 65535 (got "70000" from env PORT)`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := Load(nil, env(map[string]string{"PORT": tc.port}))
+			var verr *ValidationError
+			require.ErrorAs(t, err, &verr)
+			require.Equal(t, []string{tc.want}, verr.Messages())
+		})
+	}
+}
+
+func TestLoad_AdminAddr(t *testing.T) {
+	t.Log("Test that ADMIN_HOST and ADMIN_PORT are combined into the admin listen address")
+
+	for name, tc := range map[string]struct {
+		vars map[string]string
+		want string
+	}{
+		"defaultsToLocalhost": {nil, "127.0.0.1:8081"},
+		"customHostAndPort":   {map[string]string{"ADMIN_HOST": "0.0.0.0", "ADMIN_PORT": "9100"}, "0.0.0.0:9100"},
+		"ipv6Host":            {map[string]string{"ADMIN_HOST": "::1"}, "[::1]:8081"},
+		"trimmedWhitespace":   {map[string]string{"ADMIN_HOST": " 10.0.0.1 ", "ADMIN_PORT": " :9100 "}, "10.0.0.1:9100"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, _, err := Load(nil, env(tc.vars))
+			require.NoError(t, err)
+			require.Equal(t, tc.want, cfg.Server.AdminAddr())
+		})
+	}
+
+	t.Log("Test that a bad or colliding ADMIN_PORT is rejected rather than falling back to 8081")
+	for name, tc := range map[string]struct {
+		vars map[string]string
+		want string
+	}{
+		"invalidPort": {
+			map[string]string{"ADMIN_PORT": "abc"},
+			`server.adminPort: invalid integer (got "abc" from env ADMIN_PORT)`,
+		},
+		"collisionWithPublicPort": {
+			map[string]string{"PORT": "8080", "ADMIN_PORT": "8080"},
+			`server.adminPort: must differ from server.port (got "8080" from env ADMIN_PORT)`,
+		},
+		"collisionWithDefaultPublicPort": {
+			map[string]string{"ADMIN_PORT": ":8080"},
+			`server.adminPort: must differ from server.port (got "8080" from env ADMIN_PORT)`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := Load(nil, env(tc.vars))
+			var verr *ValidationError
+			require.ErrorAs(t, err, &verr)
+			require.Equal(t, []string{tc.want}, verr.Messages())
+		})
+	}
+}
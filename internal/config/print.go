@@ -0,0 +1,45 @@
+// print.go
+//
+// Renders a Config as YAML for --print-config. Secret fields are replaced so
+// the output can be pasted into tickets and chat safely; the result can be
+// loaded back with --config once the secrets are filled in.
+
+package config
+
+import (
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the value of every configured secret.
+const redacted = "[redacted]"
+
+// Redacted returns a copy of c with every non-empty secret replaced by
+// "[redacted]". Unset secrets stay empty so it is clear they are missing.
+func (c Config) Redacted() Config {
+	out := c
+	for _, f := range fields(&out) {
+		if !f.secret || f.value.IsZero() {
+			continue
+		}
+		switch f.value.Kind() {
+		case reflect.String:
+			f.value.SetString(redacted)
+		case reflect.Slice:
+			f.value.Set(reflect.ValueOf([]string{redacted}))
+		}
+	}
+	return out
+}
+
+// Print writes c to w as YAML with secrets redacted.
+func Print(w io.Writer, c Config) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,250 @@
+// settings.go
+//
+// Defines the typed service configuration. Values are layered by Load:
+// defaults, then an optional YAML/JSON file, then environment variables, then
+// command-line flags. Each field's tags name its file key (yaml), environment
+// variable (env) and flag (flag); fields tagged secret:"true" are redacted
+// when printed and have no flag so they never appear in process listings.
+
+package config
+
+import (
+	"net"
+	"strconv"
+	"time"
+)
+
+// ServiceName is the service's name as reported by /info and traces.
+const ServiceName = "toy-service"
+
+// Config is the complete static configuration of the service.
+type Config struct {
+	Service   Service   `yaml:"service"`
+	Server    Server    `yaml:"server"`
+	Limits    Limits    `yaml:"limits"`
+	CORS      CORS      `yaml:"cors"`
+	OpenAPI   OpenAPI   `yaml:"openapi"`
+	Secrets   Secrets   `yaml:"secrets"`
+	Auth      Auth      `yaml:"auth"`
+	TLS       TLS       `yaml:"tls"`
+	Tracing   Tracing   `yaml:"tracing"`
+	AccessLog AccessLog `yaml:"accessLog"`
+	Shutdown  Shutdown  `yaml:"shutdown"`
+}
+
+// Service describes the running service.
+type Service struct {
+	Env          string `yaml:"env" env:"SERVICE_ENV" flag:"env" usage:"runtime environment such as dev or prod"`
+	LogVerbosity string `yaml:"logVerbosity" env:"LOG_VERBOSITY" flag:"log-verbosity" usage:"log level: trace, debug, info, warn or error"`
+	Version      string `yaml:"version" env:"VERSION" flag:"version" usage:"service version reported by /info and /version"`
+	GitCommit    string `yaml:"gitCommit" env:"GIT_COMMIT" flag:"git-commit" usage:"git commit reported by /info and /version"`
+}
+
+// Server configures the public and admin listeners.
+type Server struct {
+	Port           int    `yaml:"port" env:"PORT" flag:"port" usage:"public TCP port"`
+	AdminHost      string `yaml:"adminHost" env:"ADMIN_HOST" flag:"admin-host" usage:"admin listener bind address"`
+	AdminPort      int    `yaml:"adminPort" env:"ADMIN_PORT" flag:"admin-port" usage:"admin listener port"`
+	UnixSocket     string `yaml:"unixSocket" env:"LISTEN_UNIX_SOCKET" flag:"unix-socket" usage:"serve the public listener on this Unix socket instead of TCP"`
+	UnixSocketMode string `yaml:"unixSocketMode" env:"LISTEN_UNIX_SOCKET_MODE" flag:"unix-socket-mode" usage:"octal permissions for the Unix socket"`
+	H2C            bool   `yaml:"h2c" env:"H2C" flag:"h2c" usage:"accept HTTP/2 over cleartext"`
+	PublicMetrics  bool   `yaml:"publicMetrics" env:"PUBLIC_METRICS" flag:"public-metrics" usage:"also serve /metrics on the public listener"`
+
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout" env:"READ_HEADER_TIMEOUT" flag:"read-header-timeout" usage:"time allowed to read request headers"`
+	ReadTimeout       time.Duration `yaml:"readTimeout" env:"READ_TIMEOUT" flag:"read-timeout" usage:"time allowed to read a whole request; 0 for none"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout" env:"WRITE_TIMEOUT" flag:"write-timeout" usage:"time allowed to write a response; 0 for none"`
+	IdleTimeout       time.Duration `yaml:"idleTimeout" env:"IDLE_TIMEOUT" flag:"idle-timeout" usage:"keep-alive idle time between requests"`
+}
+
+// Addr is the public TCP listen address.
+func (s Server) Addr() string {
+	return ":" + strconv.Itoa(s.Port)
+}
+
+// AdminAddr is the admin listen address.
+func (s Server) AdminAddr() string {
+	return net.JoinHostPort(s.AdminHost, strconv.Itoa(s.AdminPort))
+}
+
+// Limits bounds what clients of the public listener may send.
+type Limits struct {
+	MaxHeaderBytes ByteSize   `yaml:"maxHeaderBytes" env:"MAX_HEADER_BYTES" flag:"max-header-bytes" usage:"maximum size of request headers"`
+	MaxConnections int        `yaml:"maxConnections" env:"MAX_CONNECTIONS" flag:"max-connections" usage:"maximum concurrent connections; 0 for unlimited"`
+	MaxBodyBytes   ByteSize   `yaml:"maxBodyBytes" env:"MAX_BODY_BYTES" flag:"max-body-bytes" usage:"maximum request body size for paths without their own limit"`
+	BodyLimits     BodyLimits `yaml:"bodyLimits" env:"BODY_LIMITS" flag:"body-limits" usage:"per-path body limits, e.g. /echo=64KiB"`
+}
+
+// BodyLimit returns the body limit for path.
+func (l Limits) BodyLimit(path string) ByteSize {
+	if v, ok := l.BodyLimits[path]; ok {
+		return v
+	}
+	return l.MaxBodyBytes
+}
+
+// CORS configures cross-origin access to the public API. An Environments
+// entry for the service environment overlays the top-level policy, and
+// Routes overlay the result for a path and everything below it (see cors.go).
+type CORS struct {
+	AllowedOrigins   []string      `yaml:"allowedOrigins" env:"CORS_ALLOWED_ORIGINS" flag:"cors-allowed-origins" usage:"origins allowed cross-origin access: exact, * or https://*.example.com"`
+	AllowedMethods   []string      `yaml:"allowedMethods" env:"CORS_ALLOWED_METHODS" flag:"cors-allowed-methods" usage:"methods allowed cross-origin"`
+	AllowedHeaders   []string      `yaml:"allowedHeaders" env:"CORS_ALLOWED_HEADERS" flag:"cors-allowed-headers" usage:"request headers allowed cross-origin; * for any"`
+	ExposedHeaders   []string      `yaml:"exposedHeaders" env:"CORS_EXPOSED_HEADERS" flag:"cors-exposed-headers" usage:"response headers readable by scripts"`
+	AllowCredentials bool          `yaml:"allowCredentials" env:"CORS_ALLOW_CREDENTIALS" flag:"cors-allow-credentials" usage:"allow cookies and HTTP authentication"`
+	MaxAge           time.Duration `yaml:"maxAge" env:"CORS_MAX_AGE" flag:"cors-max-age" usage:"how long browsers may cache a preflight"`
+
+	// Environments maps a service environment (e.g. prod) to its policy.
+	Environments map[string]CORSPolicy `yaml:"environments"`
+	// Routes maps a path prefix (e.g. /echo) to its policy.
+	Routes map[string]CORSPolicy `yaml:"routes"`
+}
+
+// CORSPolicy overrides the CORS settings for an environment or route.
+// Fields left unset inherit the policy being overridden.
+type CORSPolicy struct {
+	AllowedOrigins   []string      `yaml:"allowedOrigins,omitempty"`
+	AllowedMethods   []string      `yaml:"allowedMethods,omitempty"`
+	AllowedHeaders   []string      `yaml:"allowedHeaders,omitempty"`
+	ExposedHeaders   []string      `yaml:"exposedHeaders,omitempty"`
+	AllowCredentials *bool         `yaml:"allowCredentials,omitempty"`
+	MaxAge           time.Duration `yaml:"maxAge,omitempty"`
+	// Disabled turns CORS off, ignoring every other field.
+	Disabled bool `yaml:"disabled,omitempty"`
+}
+
+// Secrets configures file-mounted secrets and the directory watcher.
+type Secrets struct {
+	Dir               string        `yaml:"dir" env:"SECRET_FILE_DIR" flag:"secret-dir" usage:"directory holding mounted secrets"`
+	Schema            string        `yaml:"schema" env:"SECRET_SCHEMA" flag:"secret-schema" usage:"declared secrets, e.g. FAKE_SECRET,API_TOKEN?"`
+	FakeSecret        string        `yaml:"fakeSecret" env:"FAKE_SECRET" secret:"true"`
+	Watch             bool          `yaml:"watch" env:"SECRET_WATCH" flag:"secret-watch" usage:"reload secrets when the directory changes"`
+	WatchPoll         bool          `yaml:"watchPoll" env:"SECRET_WATCH_POLL" flag:"secret-watch-poll" usage:"poll instead of using inotify"`
+	WatchDebounce     time.Duration `yaml:"watchDebounce" env:"SECRET_WATCH_DEBOUNCE" flag:"secret-watch-debounce" usage:"quiet period before reloading"`
+	WatchPollInterval time.Duration `yaml:"watchPollInterval" env:"SECRET_WATCH_POLL_INTERVAL" flag:"secret-watch-poll-interval" usage:"polling interval"`
+}
+
+// Auth configures the policies guarding operational endpoints.
+type Auth struct {
+	AdminToken              string        `yaml:"adminToken" env:"ADMIN_TOKEN" secret:"true"`
+	ReloadTokens            []string      `yaml:"reloadTokens" env:"RELOAD_TOKENS" secret:"true"`
+	ReloadHMACSecret        string        `yaml:"reloadHmacSecret" env:"RELOAD_HMAC_SECRET" secret:"true"`
+	ReloadHMACWindow        time.Duration `yaml:"reloadHmacWindow" env:"RELOAD_HMAC_WINDOW" flag:"reload-hmac-window" usage:"accepted clock skew for signed reloads"`
+	ReloadAllowedNetworks   []string      `yaml:"reloadAllowedNetworks" env:"RELOAD_ALLOWED_NETWORKS" flag:"reload-allowed-networks" usage:"networks allowed to call /-/reload; * for any"`
+	InternalTokens          []string      `yaml:"internalTokens" env:"INTERNAL_TOKENS" secret:"true"`
+	InternalAllowedNetworks []string      `yaml:"internalAllowedNetworks" env:"INTERNAL_ALLOWED_NETWORKS" flag:"internal-allowed-networks" usage:"networks allowed to call /internal/*; * for any"`
+}
+
+// TLS configures HTTPS and mutual TLS on the public listener.
+type TLS struct {
+	CertFile       string        `yaml:"certFile" env:"TLS_CERT_FILE" flag:"tls-cert-file" usage:"PEM certificate; enables TLS with tls-key-file"`
+	KeyFile        string        `yaml:"keyFile" env:"TLS_KEY_FILE" flag:"tls-key-file" usage:"PEM private key"`
+	ClientCAFile   string        `yaml:"clientCAFile" env:"TLS_CLIENT_CA_FILE" flag:"tls-client-ca-file" usage:"CA bundle for verifying client certificates (mTLS)"`
+	ClientAuth     string        `yaml:"clientAuth" env:"TLS_CLIENT_AUTH" flag:"tls-client-auth" usage:"require or optional"`
+	ReloadInterval time.Duration `yaml:"reloadInterval" env:"TLS_RELOAD_INTERVAL" flag:"tls-reload-interval" usage:"how often certificate files are checked"`
+}
+
+// OpenAPI configures runtime validation against the embedded OpenAPI spec
+// and how the spec and API explorer are served.
+type OpenAPI struct {
+	Validation string `yaml:"validation" env:"OPENAPI_VALIDATION" flag:"openapi-validation" usage:"off, enforce (reject invalid requests) or shadow (also log invalid responses)"`
+	ServerURL  string `yaml:"serverURL" env:"OPENAPI_SERVER_URL" flag:"openapi-server-url" usage:"public base URL listed in the served spec (default: the request's origin)"`
+	Explorer   bool   `yaml:"explorer" env:"OPENAPI_EXPLORER" flag:"openapi-explorer" usage:"serve the API explorer at /docs/"`
+}
+
+// Tracing configures the OpenTelemetry span exporter.
+type Tracing struct {
+	Exporter string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" flag:"traces-exporter" usage:"none, otlp, stdout or file"`
+	Endpoint string `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" flag:"otlp-endpoint" usage:"OTLP/HTTP collector endpoint"`
+	File     string `yaml:"file" env:"TRACES_FILE" flag:"traces-file" usage:"output path for the file exporter"`
+}
+
+// AccessLog configures the per-request access log.
+type AccessLog struct {
+	TrustedProxies []string `yaml:"trustedProxies" env:"TRUSTED_PROXIES" flag:"trusted-proxies" usage:"proxies allowed to set X-Forwarded-For"`
+	HealthzSample  uint64   `yaml:"healthzSample" env:"ACCESS_LOG_HEALTHZ_SAMPLE" flag:"access-log-healthz-sample" usage:"log 1 in N successful probe requests"`
+}
+
+// Shutdown configures the graceful drain.
+type Shutdown struct {
+	Delay   time.Duration `yaml:"delay" env:"SHUTDOWN_DELAY" flag:"shutdown-delay" usage:"keep serving this long after readiness fails"`
+	Timeout time.Duration `yaml:"timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"deadline for in-flight requests"`
+}
+
+// Default returns the configuration used when nothing is overridden.
+func Default() Config {
+	return Config{
+		Service: Service{
+			Env:          "dev",
+			LogVerbosity: "info",
+			Version:      DefaultVersion,
+			GitCommit:    "unknown",
+		},
+		Server: Server{
+			Port:           8080,
+			AdminHost:      "127.0.0.1",
+			AdminPort:      8081,
+			UnixSocketMode: "0660",
+			PublicMetrics:  true,
+
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      15 * time.Second,
+			IdleTimeout:       60 * time.Second,
+		},
+		Limits: Limits{
+			MaxHeaderBytes: MiB,
+			MaxBodyBytes:   MiB,
+		},
+		CORS: CORS{
+			AllowedMethods: []string{"GET", "POST", "HEAD", "OPTIONS"},
+			AllowedHeaders: []string{"*"},
+			ExposedHeaders: []string{"X-Request-Id", "Deprecation", "Sunset", "Link"},
+			MaxAge:         5 * time.Minute,
+		},
+		OpenAPI: OpenAPI{
+			Validation: "off",
+			Explorer:   true,
+		},
+		Secrets: Secrets{
+			Dir:               DefaultSecretDir,
+			Watch:             true,
+			WatchDebounce:     500 * time.Millisecond,
+			WatchPollInterval: 10 * time.Second,
+		},
+		Auth: Auth{
+			ReloadHMACWindow: 5 * time.Minute,
+		},
+		TLS: TLS{
+			ClientAuth:     "require",
+			ReloadInterval: 10 * time.Second,
+		},
+		Tracing: Tracing{
+			Exporter: "none",
+			File:     "traces.json",
+		},
+		AccessLog: AccessLog{
+			HealthzSample: 10,
+		},
+		Shutdown: Shutdown{
+			Timeout: 5 * time.Second,
+		},
+	}
+}
+
+// Snapshot returns the initial runtime snapshot for c.
+func (c Config) Snapshot() Snapshot {
+	secrets := map[string]string{}
+	if c.Secrets.FakeSecret != "" {
+		secrets[FakeSecretName] = c.Secrets.FakeSecret
+	}
+
+	return Snapshot{
+		Name:         ServiceName,
+		Env:          c.Service.Env,
+		Version:      c.Service.Version,
+		GitCommit:    c.Service.GitCommit,
+		SecretDir:    c.Secrets.Dir,
+		SecretSchema: c.Secrets.Schema,
+		Secrets:      secrets,
+	}
+}
@@ -0,0 +1,66 @@
+// store.go
+//
+// Holds the current configuration Snapshot behind an atomic pointer so
+// request handlers can read it without locks while reloads swap in a new
+// snapshot atomically.
+
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Provider is implemented by anything that can return the current snapshot.
+// Handlers depend on Provider rather than Store so tests can supply fixed values.
+type Provider interface {
+	Load() *Snapshot
+}
+
+// Store publishes configuration snapshots. The zero value is not usable;
+// construct one with NewStore.
+type Store struct {
+	current atomic.Pointer[Snapshot]
+	// mu serialises writers so generations are assigned without gaps.
+	mu sync.Mutex
+}
+
+// NewStore returns a Store publishing initial as generation 1.
+func NewStore(initial Snapshot) *Store {
+	s := &Store{}
+	initial.Generation = 1
+	s.current.Store(&initial)
+	return s
+}
+
+// Load returns the current snapshot. The result must be treated as read-only.
+func (s *Store) Load() *Snapshot {
+	return s.current.Load()
+}
+
+// Update copies the current snapshot, lets fn modify the copy and publishes it
+// with the next generation number. If fn returns an error nothing is published
+// and the error is returned alongside the unchanged current snapshot.
+func (s *Store) Update(fn func(next *Snapshot) error) (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur := s.current.Load()
+	next := *cur
+	if err := fn(&next); err != nil {
+		return cur, err
+	}
+	next.Generation = cur.Generation + 1
+	s.current.Store(&next)
+	return &next, nil
+}
+
+// Static is a Provider that always returns the same snapshot. It is useful in
+// tests and for embedders that never reload configuration.
+type Static Snapshot
+
+// Load returns the fixed snapshot.
+func (s Static) Load() *Snapshot {
+	snap := Snapshot(s)
+	return &snap
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_UpdateIncrementsGeneration(t *testing.T) {
+	s := NewStore(Snapshot{Name: "toy-service", Env: "one"})
+	first := s.Load()
+	require.Equal(t, uint64(1), first.Generation)
+
+	next, err := s.Update(func(n *Snapshot) error {
+		n.Env = "two"
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), next.Generation)
+	require.Equal(t, "two", s.Load().Env)
+
+	// Previously loaded snapshots are never mutated.
+	require.Equal(t, "one", first.Env)
+	require.Equal(t, uint64(1), first.Generation)
+}
+
+func TestStore_UpdateErrorPublishesNothing(t *testing.T) {
+	s := NewStore(Snapshot{Env: "keep"})
+
+	cur, err := s.Update(func(n *Snapshot) error {
+		n.Env = "discard"
+		return errors.New("boom")
+	})
+	require.Error(t, err)
+	require.Equal(t, "keep", cur.Env)
+	require.Equal(t, uint64(1), s.Load().Generation)
+	require.Equal(t, "keep", s.Load().Env)
+}
+
+func TestStore_ConcurrentUpdatesAreSerialised(t *testing.T) {
+	s := NewStore(Snapshot{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = s.Update(func(n *Snapshot) error { return nil })
+			_ = s.Load().Generation
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, uint64(51), s.Load().Generation)
+}
+
+func TestStatic(t *testing.T) {
+	p := Static{Name: "toy-service", Generation: 7}
+	require.Equal(t, "toy-service", p.Load().Name)
+	require.Equal(t, uint64(7), p.Load().Generation)
+}
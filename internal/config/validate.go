@@ -0,0 +1,261 @@
+// validate.go
+//
+// Validates a loaded Config, collecting every problem rather than stopping
+// at the first so operators can fix a bad deployment in one pass.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paulcapestany/toy-service/internal/loglevel"
+	"github.com/paulcapestany/toy-service/internal/netutil"
+)
+
+// FieldError describes one invalid configuration value.
+type FieldError struct {
+	// Field is the dotted path of the value, e.g. "server.port".
+	Field   string
+	Message string
+	// Value and Source describe the offending value and where it was set
+	// (e.g. "env PORT", "flag --port", "file config.yaml" or "default").
+	Value  string
+	Source string
+
+	secret bool
+}
+
+func (e FieldError) Error() string {
+	// Unset defaults add nothing beyond the message itself.
+	if e.Source == "" || (e.Source == "default" && e.Value == "") {
+		return e.Field + ": " + e.Message
+	}
+	value := strconv.Quote(e.Value)
+	if e.secret {
+		value = redacted
+	}
+	return fmt.Sprintf("%s: %s (got %s from %s)", e.Field, e.Message, value, e.Source)
+}
+
+// ValidationError lists every invalid field found by Load.
+type ValidationError struct {
+	Problems []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := e.Messages()
+	if len(msgs) == 1 {
+		return "invalid configuration: " + msgs[0]
+	}
+	return fmt.Sprintf("invalid configuration (%d problems): %s", len(msgs), strings.Join(msgs, "; "))
+}
+
+// Messages returns one line per problem.
+func (e *ValidationError) Messages() []string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.Error()
+	}
+	return msgs
+}
+
+// Validate checks a configuration built in code rather than by Load, running
+// the same checks and validators. It returns a *ValidationError.
+func Validate(c Config, validators ...Validator) error {
+	if problems := validate(&c, validators); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validate checks c and runs the extra validators.
+func validate(c *Config, validators []Validator) []FieldError {
+	var problems []FieldError
+	fail := func(path, format string, args ...any) {
+		problems = append(problems, FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if _, err := loglevel.Parse(c.Service.LogVerbosity); err != nil {
+		fail("service.logVerbosity", "must be one of trace, debug, info, warn, error")
+	}
+
+	if !validPort(c.Server.Port) {
+		fail("server.port", "must be between 1 and 65535")
+	}
+	if !validPort(c.Server.AdminPort) {
+		fail("server.adminPort", "must be between 1 and 65535")
+	} else if c.Server.UnixSocket == "" && c.Server.AdminPort == c.Server.Port {
+		fail("server.adminPort", "must differ from server.port")
+	}
+	if mode, err := strconv.ParseUint(c.Server.UnixSocketMode, 8, 32); err != nil || mode > 0o777 {
+		fail("server.unixSocketMode", "must be an octal file mode such as 0660")
+	}
+	if c.Server.H2C && c.TLS.CertFile != "" {
+		fail("server.h2c", "cannot be combined with TLS; HTTP/2 is already negotiated over TLS")
+	}
+
+	for _, t := range []struct {
+		path string
+		d    time.Duration
+	}{
+		{"server.readHeaderTimeout", c.Server.ReadHeaderTimeout},
+		{"server.readTimeout", c.Server.ReadTimeout},
+		{"server.writeTimeout", c.Server.WriteTimeout},
+		{"server.idleTimeout", c.Server.IdleTimeout},
+	} {
+		if t.d < 0 {
+			fail(t.path, "must not be negative")
+		}
+	}
+
+	if c.Limits.MaxConnections < 0 {
+		fail("limits.maxConnections", "must not be negative")
+	}
+	if c.Limits.MaxBodyBytes <= 0 {
+		fail("limits.maxBodyBytes", "must be positive")
+	}
+	if c.Limits.MaxHeaderBytes < 0 {
+		fail("limits.maxHeaderBytes", "must not be negative")
+	}
+	for _, path := range c.Limits.BodyLimits.Paths() {
+		if !strings.HasPrefix(path, "/") || c.Limits.BodyLimits[path] <= 0 {
+			fail("limits.bodyLimits", "%s=%s: want a positive size for a path starting with /", path, c.Limits.BodyLimits[path])
+		}
+	}
+
+	if _, ok := c.CORS.Environments[c.Service.Env]; !ok {
+		if msg := validateCORSPolicy(c.CORS.Policy(c.Service.Env)); msg != "" {
+			fail("cors.allowedOrigins", "%s", msg)
+		}
+	}
+	for _, env := range sortedKeys(c.CORS.Environments) {
+		if msg := validateCORSPolicy(c.CORS.Policy(env)); msg != "" {
+			fail("cors.environments", "%s: %s", env, msg)
+		}
+	}
+	for _, prefix := range sortedKeys(c.CORS.Routes) {
+		if !strings.HasPrefix(prefix, "/") {
+			fail("cors.routes", "%s: must be a path starting with /", prefix)
+		} else if msg := validateCORSPolicy(c.CORS.RoutePolicies(c.Service.Env)[prefix]); msg != "" {
+			fail("cors.routes", "%s: %s", prefix, msg)
+		}
+	}
+
+	switch strings.ToLower(c.OpenAPI.Validation) {
+	case "off", "enforce", "shadow":
+	default:
+		fail("openapi.validation", "must be one of off, enforce, shadow")
+	}
+	if c.OpenAPI.ServerURL != "" {
+		if u, err := url.Parse(c.OpenAPI.ServerURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("openapi.serverURL", "must be an absolute http or https URL")
+		}
+	}
+
+	if c.Secrets.WatchDebounce < 0 {
+		fail("secrets.watchDebounce", "must not be negative")
+	}
+	if c.Secrets.WatchPollInterval <= 0 {
+		fail("secrets.watchPollInterval", "must be positive")
+	}
+
+	// A blank token would match an "Authorization: Bearer" header with no
+	// token, so it is rejected rather than silently accepted.
+	if c.Auth.AdminToken != "" && strings.TrimSpace(c.Auth.AdminToken) == "" {
+		fail("auth.adminToken", "must not be blank")
+	}
+	if hasBlank(c.Auth.ReloadTokens) {
+		fail("auth.reloadTokens", "must not contain empty tokens")
+	}
+	if hasBlank(c.Auth.InternalTokens) {
+		fail("auth.internalTokens", "must not contain empty tokens")
+	}
+	if c.Auth.ReloadHMACWindow <= 0 {
+		fail("auth.reloadHmacWindow", "must be positive")
+	}
+	if err := validNetworks(c.Auth.ReloadAllowedNetworks); err != nil {
+		fail("auth.reloadAllowedNetworks", "%v", err)
+	}
+	if err := validNetworks(c.Auth.InternalAllowedNetworks); err != nil {
+		fail("auth.internalAllowedNetworks", "%v", err)
+	}
+
+	if c.TLS.CertFile != "" && c.TLS.KeyFile == "" {
+		fail("tls.keyFile", "is required when tls.certFile is set")
+	}
+	if c.TLS.KeyFile != "" && c.TLS.CertFile == "" {
+		fail("tls.certFile", "is required when tls.keyFile is set")
+	}
+	if c.TLS.ClientCAFile != "" && c.TLS.CertFile == "" {
+		fail("tls.clientCAFile", "requires tls.certFile and tls.keyFile")
+	}
+	switch strings.ToLower(c.TLS.ClientAuth) {
+	case "require", "optional":
+	default:
+		fail("tls.clientAuth", "must be require or optional")
+	}
+	if c.TLS.ReloadInterval <= 0 {
+		fail("tls.reloadInterval", "must be positive")
+	}
+
+	switch strings.ToLower(c.Tracing.Exporter) {
+	case "none", "otlp", "stdout", "console", "file":
+	default:
+		fail("tracing.exporter", "must be one of none, otlp, stdout, file")
+	}
+
+	if _, err := netutil.ParseNetworks(strings.Join(c.AccessLog.TrustedProxies, ",")); err != nil {
+		fail("accessLog.trustedProxies", "%v", err)
+	}
+
+	if c.Shutdown.Delay < 0 {
+		fail("shutdown.delay", "must not be negative")
+	}
+	if c.Shutdown.Timeout <= 0 {
+		fail("shutdown.timeout", "must be positive")
+	}
+
+	for _, v := range validators {
+		if err := v.Check(c); err != nil {
+			fail(v.Field, "%v", err)
+		}
+	}
+	return problems
+}
+
+func validPort(p int) bool {
+	return p >= 1 && p <= 65535
+}
+
+// hasBlank reports whether list contains an empty or whitespace-only entry.
+func hasBlank(list []string) bool {
+	for _, s := range list {
+		if strings.TrimSpace(s) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// validNetworks checks a list of IPs or CIDRs; "*" alone means anywhere.
+func validNetworks(list []string) error {
+	if len(list) == 0 || (len(list) == 1 && list[0] == "*") {
+		return nil
+	}
+	_, err := netutil.ParseNetworks(strings.Join(list, ","))
+	return err
+}
+
+func sortedKeys(m map[string]CORSPolicy) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
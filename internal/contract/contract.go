@@ -0,0 +1,262 @@
+// contract.go
+//
+// Validates live traffic against the OpenAPI spec. In enforce mode requests
+// that do not match the contract (path and query parameters, headers, body)
+// are rejected with a 400 validation problem before reaching a handler;
+// shadow mode additionally validates responses, logging and counting
+// violations without touching what the client receives. Routes the spec
+// does not describe are passed through to the router untouched; the spec's
+// paths are matched with or without an API version prefix.
+
+package contract
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/paulcapestany/toy-service/internal/apiversion"
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/logctx"
+	"github.com/paulcapestany/toy-service/internal/problem"
+)
+
+// Mode selects what the middleware validates.
+type Mode string
+
+const (
+	// Off disables validation.
+	Off Mode = "off"
+	// Enforce rejects requests that do not match the spec.
+	Enforce Mode = "enforce"
+	// Shadow enforces requests like Enforce and also validates responses,
+	// reporting violations without altering the response.
+	Shadow Mode = "shadow"
+)
+
+// Directions label violations in logs and metrics.
+const (
+	Request  = "request"
+	Response = "response"
+)
+
+// maxResponseBytes caps how much of a response is buffered for shadow
+// validation; larger responses are passed through unvalidated.
+const maxResponseBytes = 1 << 20
+
+// Observer is notified of every contract violation. metrics.Metrics
+// satisfies it.
+type Observer interface {
+	ObserveContractViolation(direction, route string)
+}
+
+// Options configures the validation middleware.
+type Options struct {
+	Mode Mode
+	// Observer, when set, counts violations.
+	Observer Observer
+	// Prefixes are the API version prefixes (e.g. "/v1") the spec's paths
+	// are also served under.
+	Prefixes []string
+}
+
+// OptionsFromConfig builds Options from the openapi configuration.
+func OptionsFromConfig(cfg config.OpenAPI) Options {
+	return Options{Mode: Mode(strings.ToLower(cfg.Validation))}
+}
+
+// New parses spec and returns middleware validating traffic against it. An
+// invalid spec is an error, so a broken contract stops the server at
+// startup. With validation off the spec is not parsed and the middleware
+// passes every request through.
+func New(spec []byte, opts Options) (func(http.Handler) http.Handler, error) {
+	if opts.Mode == "" || opts.Mode == Off {
+		return func(next http.Handler) http.Handler { return next }, nil
+	}
+
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("load OpenAPI spec: %w", err)
+	}
+	// Match on the path alone: the spec's servers only list the local
+	// development URL.
+	doc.Servers = nil
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &validator{router: router, opts: opts}
+	return v.middleware, nil
+}
+
+type validator struct {
+	router routers.Router
+	opts   Options
+}
+
+func (v *validator) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, params, err := v.findRoute(r)
+		if err != nil {
+			// Unknown paths and methods are left to the router's 404 and 405.
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		logger := logctx.From(r.Context())
+
+		in := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: params,
+			Route:      route,
+			Options:    filterOptions(),
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), in); err != nil {
+			v.observe(Request, route.Path)
+			logger.Warn().Err(err).Str("route", route.Path).Msg("Rejected request: does not match the OpenAPI contract")
+			problem.Write(w, r, requestProblem(err))
+			return
+		}
+
+		if v.opts.Mode != Shadow {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body := &capture{}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(body)
+		next.ServeHTTP(ww, r)
+
+		if body.overflow {
+			logger.Debug().Str("route", route.Path).Msg("Skipped response validation: body too large")
+			return
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		err = openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: in,
+			Status:                 status,
+			Header:                 ww.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(body.buf.Bytes())),
+			Options:                filterOptions(),
+		})
+		if err != nil {
+			v.observe(Response, route.Path)
+			logger.Warn().Err(err).
+				Str("route", route.Path).
+				Str("method", r.Method).
+				Int("status", status).
+				Msg("Response does not match the OpenAPI contract")
+		}
+	})
+}
+
+// findRoute finds the spec operation for r, looking /v1/echo up as /echo.
+func (v *validator) findRoute(r *http.Request) (*routers.Route, map[string]string, error) {
+	path := apiversion.Unversioned(r.URL.Path, v.opts.Prefixes)
+	if path == r.URL.Path {
+		return v.router.FindRoute(r)
+	}
+	u := *r.URL
+	u.Path, u.RawPath = path, ""
+	lookup := *r
+	lookup.URL = &u
+	return v.router.FindRoute(&lookup)
+}
+
+func (v *validator) observe(direction, route string) {
+	if v.opts.Observer != nil {
+		v.opts.Observer.ObserveContractViolation(direction, route)
+	}
+}
+
+// filterOptions collects every violation rather than stopping at the first,
+// flags undocumented response statuses, and keeps schema errors to the
+// failing path and reason instead of dumping the whole schema into logs.
+func filterOptions() *openapi3filter.Options {
+	opts := &openapi3filter.Options{
+		MultiError:            true,
+		IncludeResponseStatus: true,
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+	}
+	opts.WithCustomSchemaErrorFunc(func(err *openapi3.SchemaError) string {
+		return fmt.Sprintf("at %q: %s", "/"+strings.Join(err.JSONPointer(), "/"), err.Reason)
+	})
+	return opts
+}
+
+// capture buffers a copy of the response body up to maxResponseBytes.
+type capture struct {
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	if c.overflow {
+		return len(p), nil
+	}
+	if c.buf.Len()+len(p) > maxResponseBytes {
+		c.overflow = true
+		c.buf = bytes.Buffer{}
+		return len(p), nil
+	}
+	return c.buf.Write(p)
+}
+
+// requestProblem turns a request validation error into the problem sent to
+// the client: 413 when the body exceeded its limit, otherwise a validation
+// problem listing each offending field.
+func requestProblem(err error) *problem.Problem {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return problem.New(http.StatusRequestEntityTooLarge, fmt.Sprintf("Payload too large (max %s)", config.ByteSize(maxBytesErr.Limit)))
+	}
+	return problem.Validation("Invalid input", fieldErrors(err, "body")...)
+}
+
+// fieldErrors flattens kin-openapi's nested errors into one FieldError per
+// failure. field names the parameter (or "body") the error belongs to.
+func fieldErrors(err error, field string) []problem.FieldError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var out []problem.FieldError
+		for _, err := range e {
+			out = append(out, fieldErrors(err, field)...)
+		}
+		return out
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			field = e.Parameter.Name
+		}
+		switch e.Err.(type) {
+		case openapi3.MultiError, *openapi3.SchemaError:
+			return fieldErrors(e.Err, field)
+		case nil:
+			return []problem.FieldError{{Field: field, Detail: e.Reason}}
+		}
+		if e.Reason == "" {
+			return []problem.FieldError{{Field: field, Detail: e.Err.Error()}}
+		}
+		return []problem.FieldError{{Field: field, Detail: e.Reason + ": " + e.Err.Error()}}
+	case *openapi3.SchemaError:
+		// Body fields are named by their JSON path; parameters by name.
+		if pointer := e.JSONPointer(); field == "body" && len(pointer) > 0 {
+			field = strings.Join(pointer, ".")
+		}
+		return []problem.FieldError{{Field: field, Detail: e.Reason}}
+	}
+	return []problem.FieldError{{Field: field, Detail: err.Error()}}
+}
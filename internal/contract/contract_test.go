@@ -0,0 +1,202 @@
+package contract
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/bodylimit"
+	"github.com/paulcapestany/toy-service/internal/problem"
+	"github.com/paulcapestany/toy-service/spec"
+)
+
+type violations []string
+
+func (v *violations) ObserveContractViolation(direction, route string) {
+	*v = append(*v, direction+" "+route)
+}
+
+// newRouter serves /echo by echoing the request body, /livez with a valid
+// report and /version with a body that breaks the contract, behind the
+// validation middleware.
+func newRouter(t *testing.T, mode Mode, observed *violations) *chi.Mux {
+	t.Helper()
+	validate, err := New(spec.OpenAPI, Options{Mode: mode, Observer: observed})
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(bodylimit.New(bodylimit.Options{Default: 64}))
+	r.Use(validate)
+	r.Post("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+	r.Get("/livez", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	})
+	r.Get("/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"toy-service"}`))
+	})
+	r.Get("/undocumented", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return r
+}
+
+func post(r http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNew_Enforce(t *testing.T) {
+	t.Log("Test that requests breaking the contract are rejected with a validation problem")
+
+	var observed violations
+	r := newRouter(t, Enforce, &observed)
+
+	rec := post(r, `{"message":""}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+	var p problem.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	require.Equal(t, problem.TypeValidation, p.Type)
+	require.Equal(t, "Invalid input", p.Detail)
+	require.Len(t, p.Errors, 1)
+	require.Equal(t, "message", p.Errors[0].Field)
+	require.Contains(t, p.Errors[0].Detail, "minimum string length is 1")
+	require.Equal(t, violations{"request /echo"}, observed)
+
+	t.Log("Test that a missing required property is reported")
+	rec = post(r, `{"msg":"hi"}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	require.Equal(t, "message", p.Errors[0].Field)
+	require.Contains(t, p.Errors[0].Detail, "is missing")
+
+	t.Log("Test that a valid request reaches the handler with its body intact")
+	rec = post(r, `{"message":"hi"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"message":"hi"}`, rec.Body.String())
+
+	t.Log("Test that responses are not validated outside shadow mode")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, violations{"request /echo", "request /echo"}, observed)
+}
+
+func TestNew_EnforceRejectsWrongContentType(t *testing.T) {
+	r := newRouter(t, Enforce, &violations{})
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`message=hi`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var p problem.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	require.Equal(t, "body", p.Errors[0].Field)
+	require.Contains(t, p.Errors[0].Detail, "Content-Type")
+}
+
+func TestNew_EnforceTooLarge(t *testing.T) {
+	t.Log("Test that bodies over the limit are answered with 413, not a validation problem")
+
+	r := newRouter(t, Enforce, &violations{})
+	rec := post(r, `{"message":"`+strings.Repeat("a", 100)+`"}`)
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	var p problem.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	require.Equal(t, "Payload too large (max 64B)", p.Detail)
+}
+
+func TestNew_PassesThroughUnknownRoutes(t *testing.T) {
+	t.Log("Test that routes missing from the spec are left to the router")
+
+	var observed violations
+	r := newRouter(t, Shadow, &observed)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/undocumented", nil))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Empty(t, observed)
+}
+
+func TestNew_VersionPrefixes(t *testing.T) {
+	t.Log("Test that the spec's paths are validated under an API version prefix too")
+
+	var observed violations
+	validate, err := New(spec.OpenAPI, Options{Mode: Enforce, Observer: &observed, Prefixes: []string{"/v1"}})
+	require.NoError(t, err)
+	r := chi.NewRouter()
+	r.Use(validate)
+	r.Post("/v1/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	})
+
+	for body, want := range map[string]int{`{"message":""}`: http.StatusBadRequest, `{"message":"hi"}`: http.StatusOK} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/echo", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		require.Equal(t, want, rec.Code, body)
+	}
+	require.Equal(t, violations{"request /echo"}, observed)
+}
+
+func TestNew_Shadow(t *testing.T) {
+	t.Log("Test that shadow mode reports invalid responses without changing them")
+
+	var observed violations
+	r := newRouter(t, Shadow, &observed)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"name":"toy-service"}`, rec.Body.String())
+	require.Equal(t, violations{"response /version"}, observed)
+
+	t.Log("Test that valid responses are not reported")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, violations{"response /version"}, observed)
+
+	t.Log("Test that invalid requests are still rejected in shadow mode")
+	rec = post(r, `{"message":""}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, violations{"response /version", "request /echo"}, observed)
+}
+
+func TestNew_Off(t *testing.T) {
+	var observed violations
+	r := newRouter(t, Off, &observed)
+
+	rec := post(r, `{"message":""}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, observed)
+}
+
+func TestNew_InvalidSpec(t *testing.T) {
+	_, err := New([]byte("openapi: 3.0.3\npaths: {}\n"), Options{Mode: Enforce})
+	require.Error(t, err)
+}
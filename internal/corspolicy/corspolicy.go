@@ -0,0 +1,190 @@
+// corspolicy.go
+//
+// Applies the configured CORS policy (see config.CORS) to the public router:
+// the policy for the service environment, overridden per path prefix. Paths
+// of operational routes never get CORS headers, whatever the configuration
+// says, and rejected preflight requests are answered with 403 and logged.
+
+package corspolicy
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/cors"
+
+	"github.com/paulcapestany/toy-service/internal/apiversion"
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/logctx"
+)
+
+// ProtectedPrefixes are the operational route prefixes that never allow
+// cross-origin requests.
+var ProtectedPrefixes = []string{"/internal", "/-/", "/debug", "/metrics"}
+
+// Options is the resolved policy set for one environment.
+type Options struct {
+	// Default applies to paths without a route override.
+	Default config.CORSPolicy
+	// Routes maps path prefixes to their policy.
+	Routes map[string]config.CORSPolicy
+	// Prefixes are the API version prefixes (e.g. "/v1"). A path under one
+	// that no route matches gets the policy of its unversioned path.
+	Prefixes []string
+}
+
+// OptionsFromConfig resolves the policies for the service environment env.
+func OptionsFromConfig(cfg config.CORS, env string) Options {
+	return Options{Default: cfg.Policy(env), Routes: cfg.RoutePolicies(env)}
+}
+
+// RoutesValidator rejects route overrides for operational paths when the
+// configuration is loaded.
+var RoutesValidator = config.Validator{
+	Field: "cors.routes",
+	Check: func(c *config.Config) error {
+		for prefix := range c.CORS.Routes {
+			if protected(prefix) {
+				return fmt.Errorf("%s: operational routes never allow CORS", prefix)
+			}
+		}
+		return nil
+	},
+}
+
+// route pairs a path prefix with its handler.
+type route struct {
+	prefix string
+	cors   func(http.Handler) http.Handler
+}
+
+// New returns middleware applying opts. The longest matching route prefix
+// wins; a prefix matches itself and every path below it.
+func New(opts Options) func(http.Handler) http.Handler {
+	var routes []route
+	for prefix, p := range opts.Routes {
+		routes = append(routes, route{prefix: prefix, cors: handler(p)})
+	}
+	sort.Slice(routes, func(i, j int) bool { return len(routes[i].prefix) > len(routes[j].prefix) })
+	fallback := handler(opts.Default)
+
+	return func(next http.Handler) http.Handler {
+		deny := Deny(next)
+		byRoute := make([]http.Handler, len(routes))
+		for i, rt := range routes {
+			byRoute[i] = rt.cors(next)
+		}
+		def := fallback(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if protected(r.URL.Path) {
+				deny.ServeHTTP(w, r)
+				return
+			}
+			if i := match(routes, r.URL.Path); i >= 0 {
+				byRoute[i].ServeHTTP(w, r)
+				return
+			}
+			if i := match(routes, apiversion.Unversioned(r.URL.Path, opts.Prefixes)); i >= 0 {
+				byRoute[i].ServeHTTP(w, r)
+				return
+			}
+			def.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Deny serves next without CORS headers and rejects every preflight request.
+// The admin router uses it so operational routes are never reachable from a
+// browser on another origin.
+func Deny(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPreflight(r) {
+			reject(w, r, "CORS disabled for this path")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handler builds the middleware for one policy.
+func handler(p config.CORSPolicy) func(http.Handler) http.Handler {
+	if !p.Enabled() {
+		return Deny
+	}
+
+	c := cors.New(cors.Options{
+		AllowedOrigins:   p.AllowedOrigins,
+		AllowedMethods:   p.AllowedMethods,
+		AllowedHeaders:   p.AllowedHeaders,
+		ExposedHeaders:   p.ExposedHeaders,
+		AllowCredentials: p.Credentials(),
+		MaxAge:           int(p.MaxAge.Seconds()),
+		// Let preflights reach answerPreflight so rejections get a 403.
+		OptionsPassthrough: true,
+	})
+	return func(next http.Handler) http.Handler {
+		return c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isPreflight(r) {
+				answerPreflight(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
+
+// answerPreflight completes a preflight already processed by go-chi/cors,
+// which only sets Access-Control-Allow-Origin when it accepts the request.
+func answerPreflight(w http.ResponseWriter, r *http.Request) {
+	if w.Header().Get("Access-Control-Allow-Origin") == "" {
+		reject(w, r, "origin, method or headers not allowed")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func reject(w http.ResponseWriter, r *http.Request, reason string) {
+	logger := logctx.From(r.Context())
+	logger.Warn().
+		Str("origin", r.Header.Get("Origin")).
+		Str("path", r.URL.Path).
+		Str("requestMethod", r.Header.Get("Access-Control-Request-Method")).
+		Str("requestHeaders", r.Header.Get("Access-Control-Request-Headers")).
+		Str("reason", reason).
+		Msg("Rejected CORS preflight")
+	w.WriteHeader(http.StatusForbidden)
+}
+
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// match returns the index of the first of routes matching path, or -1.
+func match(routes []route, path string) int {
+	for i, rt := range routes {
+		if matches(rt.prefix, path) {
+			return i
+		}
+	}
+	return -1
+}
+
+func protected(path string) bool {
+	for _, prefix := range ProtectedPrefixes {
+		if matches(prefix, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether path is prefix or lies below it.
+func matches(prefix, path string) bool {
+	if strings.HasSuffix(prefix, "/") {
+		return strings.HasPrefix(path, prefix)
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
@@ -0,0 +1,153 @@
+package corspolicy
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+func preflight(path, origin, method string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	return req
+}
+
+func serve(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func newHandler(opts Options) http.Handler {
+	return New(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestNew_OriginsAndWildcards(t *testing.T) {
+	t.Log("Test that only listed origins and wildcard subdomains are allowed")
+
+	creds := true
+	h := newHandler(Options{Default: config.CORSPolicy{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods:   []string{"GET", "POST"},
+		ExposedHeaders:   []string{"X-Request-Id"},
+		AllowCredentials: &creds,
+		MaxAge:           time.Minute,
+	}})
+
+	w := serve(h, preflight("/echo", "https://app.example.com", "POST"))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	require.Equal(t, "60", w.Header().Get("Access-Control-Max-Age"))
+
+	w = serve(h, preflight("/echo", "https://eu.example.org", "GET"))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "https://eu.example.org", w.Header().Get("Access-Control-Allow-Origin"))
+
+	for _, req := range []*http.Request{
+		preflight("/echo", "https://evil.example.net", "POST"),
+		preflight("/echo", "https://app.example.com", "DELETE"),
+	} {
+		w = serve(h, req)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/info", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w = serve(h, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "X-Request-Id", w.Header().Get("Access-Control-Expose-Headers"))
+}
+
+func TestNew_RouteOverridesAndProtectedPaths(t *testing.T) {
+	t.Log("Test that route overrides apply by prefix and operational paths never get CORS")
+
+	h := newHandler(Options{
+		Default: config.CORSPolicy{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET", "POST"}},
+		Routes: map[string]config.CORSPolicy{
+			"/echo":  {Disabled: true},
+			"/files": {AllowedOrigins: []string{"https://files.example.com"}, AllowedMethods: []string{"GET"}},
+		},
+		Prefixes: []string{"/v1"},
+	})
+
+	require.Equal(t, http.StatusOK, serve(h, preflight("/info", "https://any.example.com", "GET")).Code)
+	require.Equal(t, http.StatusOK, serve(h, preflight("/echoes", "https://any.example.com", "GET")).Code)
+	require.Equal(t, http.StatusForbidden, serve(h, preflight("/echo", "https://any.example.com", "POST")).Code)
+	require.Equal(t, http.StatusForbidden, serve(h, preflight("/files/a", "https://any.example.com", "GET")).Code)
+	require.Equal(t, http.StatusOK, serve(h, preflight("/files/a", "https://files.example.com", "GET")).Code)
+
+	t.Log("Test that overrides for unversioned paths also apply under a version prefix")
+	require.Equal(t, http.StatusForbidden, serve(h, preflight("/v1/echo", "https://any.example.com", "POST")).Code)
+	require.Equal(t, http.StatusOK, serve(h, preflight("/v1/info", "https://any.example.com", "GET")).Code)
+	require.Equal(t, http.StatusForbidden, serve(h, preflight("/v1/files/a", "https://any.example.com", "GET")).Code)
+
+	for _, path := range []string{"/internal/config", "/-/reload", "/-/log-level", "/debug/pprof/", "/metrics"} {
+		require.Equal(t, http.StatusForbidden, serve(h, preflight(path, "https://any.example.com", "GET")).Code, path)
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Origin", "https://any.example.com")
+		w := serve(h, req)
+		require.Equal(t, http.StatusOK, w.Code, path)
+		require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), path)
+	}
+}
+
+func TestNew_LogsRejectedPreflights(t *testing.T) {
+	t.Log("Test that rejected preflights are logged with the origin and path")
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	h := newHandler(Options{Default: config.CORSPolicy{AllowedOrigins: []string{"https://app.example.com"}}})
+
+	req := preflight("/echo", "https://evil.example.net", "POST")
+	req = req.WithContext(logger.WithContext(req.Context()))
+	require.Equal(t, http.StatusForbidden, serve(h, req).Code)
+	require.Contains(t, buf.String(), `"origin":"https://evil.example.net"`)
+	require.Contains(t, buf.String(), `"path":"/echo"`)
+	require.Contains(t, buf.String(), "Rejected CORS preflight")
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	t.Log("Test that policies resolve per environment, allowing any origin by default")
+
+	cfg := config.Default().CORS
+	require.Equal(t, []string{"*"}, OptionsFromConfig(cfg, "dev").Default.AllowedOrigins)
+	require.Equal(t, []string{"*"}, OptionsFromConfig(cfg, "prod").Default.AllowedOrigins)
+
+	cfg.Environments = map[string]config.CORSPolicy{
+		"prod": {AllowedOrigins: []string{"https://app.example.com"}},
+		"dev":  {Disabled: true},
+	}
+	cfg.Routes = map[string]config.CORSPolicy{"/echo": {AllowedMethods: []string{"POST"}}}
+
+	prod := OptionsFromConfig(cfg, "prod")
+	require.Equal(t, []string{"https://app.example.com"}, prod.Default.AllowedOrigins)
+	require.Equal(t, []string{"GET", "POST", "HEAD", "OPTIONS"}, prod.Default.AllowedMethods)
+	require.Equal(t, []string{"https://app.example.com"}, prod.Routes["/echo"].AllowedOrigins)
+	require.Equal(t, []string{"POST"}, prod.Routes["/echo"].AllowedMethods)
+	require.False(t, OptionsFromConfig(cfg, "dev").Default.Enabled())
+}
+
+func TestRoutesValidator(t *testing.T) {
+	t.Log("Test that route overrides for operational paths are rejected at load time")
+
+	cfg := config.Default()
+	cfg.CORS.Routes = map[string]config.CORSPolicy{"/-/reload": {AllowedOrigins: []string{"*"}}}
+	require.Error(t, RoutesValidator.Check(&cfg))
+
+	cfg.CORS.Routes = map[string]config.CORSPolicy{"/echo": {AllowedOrigins: []string{"*"}}}
+	require.NoError(t, RoutesValidator.Check(&cfg))
+}
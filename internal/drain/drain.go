@@ -0,0 +1,141 @@
+// drain.go
+//
+// Coordinates a graceful drain on shutdown: readiness is flipped to failing
+// so load balancers stop routing new traffic, the process waits a pre-stop
+// delay for endpoint updates to propagate, then the servers stop accepting
+// connections and in-flight requests get until a deadline to finish. Requests
+// still running at the deadline are aborted and counted.
+
+package drain
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/health"
+	"github.com/paulcapestany/toy-service/internal/logctx"
+)
+
+// Options configures the drain sequence.
+type Options struct {
+	// Delay is how long to keep serving after readiness starts failing,
+	// giving load balancers time to stop routing to this instance.
+	Delay time.Duration
+	// Timeout bounds how long in-flight requests may take to finish once the
+	// servers stop accepting connections.
+	Timeout time.Duration
+}
+
+// OptionsFromConfig builds Options from the shutdown configuration.
+func OptionsFromConfig(cfg config.Shutdown) Options {
+	return Options{Delay: cfg.Delay, Timeout: cfg.Timeout}
+}
+
+// Drainer tracks in-flight requests and the drain state.
+type Drainer struct {
+	opts     Options
+	inFlight atomic.Int64
+	draining atomic.Bool
+}
+
+// New returns a Drainer using opts.
+func New(opts Options) *Drainer {
+	return &Drainer{opts: opts}
+}
+
+// Middleware counts requests while they are being served.
+func (d *Drainer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.inFlight.Add(1)
+		defer d.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// InFlight returns the number of requests currently being served.
+func (d *Drainer) InFlight() int64 {
+	return d.inFlight.Load()
+}
+
+// Draining reports whether a drain has started.
+func (d *Drainer) Draining() bool {
+	return d.draining.Load()
+}
+
+// Check returns a readiness check that fails once draining has started.
+func (d *Drainer) Check() health.Check {
+	return health.Check{
+		Name:   "drain",
+		Probes: health.Readiness,
+		Run: func(context.Context) error {
+			if d.Draining() {
+				return fmt.Errorf("draining: %d in-flight requests", d.InFlight())
+			}
+			return nil
+		},
+	}
+}
+
+// Drain runs the drain sequence against servers and returns the number of
+// requests aborted at the deadline. Cancelling ctx cuts the pre-stop delay
+// short (e.g. on a second signal) but not the in-flight deadline; a ctx
+// deadline earlier than Timeout does bring that deadline forward. Progress is
+// logged to the zerolog logger in ctx, if any.
+func (d *Drainer) Drain(ctx context.Context, servers ...*http.Server) int64 {
+	logger := logctx.From(ctx)
+	d.draining.Store(true)
+	logger.Info().
+		Dur("delay", d.opts.Delay).
+		Dur("timeout", d.opts.Timeout).
+		Int64("inFlight", d.InFlight()).
+		Msg("Draining: readiness now failing")
+
+	if d.opts.Delay > 0 {
+		timer := time.NewTimer(d.opts.Delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			logger.Warn().Msg("Pre-stop delay interrupted")
+		}
+	}
+
+	logger.Info().Int64("inFlight", d.InFlight()).Msg("Draining: no longer accepting connections")
+	deadline := time.Now().Add(d.opts.Timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	shutdownCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		once    sync.Once
+		aborted int64
+	)
+	for _, srv := range servers {
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				// Count what is still running before any server is closed.
+				once.Do(func() { aborted = d.InFlight() })
+				logger.Warn().Err(err).Str("addr", srv.Addr).Msg("Drain deadline reached; closing remaining connections")
+				_ = srv.Close()
+			}
+		}(srv)
+	}
+	wg.Wait()
+
+	if aborted > 0 {
+		logger.Warn().Int64("aborted", aborted).Msg("Drain finished with aborted requests")
+	} else {
+		logger.Info().Msg("Drain finished; all in-flight requests completed")
+	}
+	return aborted
+}
@@ -0,0 +1,126 @@
+package drain
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+// startServer serves handler through d's middleware on a loopback port.
+func startServer(t *testing.T, d *Drainer, handler http.HandlerFunc) (*http.Server, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: d.Middleware(handler)}
+	go func() { _ = srv.Serve(ln) }()
+	return srv, "http://" + ln.Addr().String()
+}
+
+// waitInFlight blocks until d reports n in-flight requests.
+func waitInFlight(t *testing.T, d *Drainer, n int64) {
+	t.Helper()
+	require.Eventually(t, func() bool { return d.InFlight() == n }, time.Second, time.Millisecond)
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	opts := OptionsFromConfig(config.Shutdown{Delay: 10 * time.Second, Timeout: 30 * time.Second})
+	require.Equal(t, Options{Delay: 10 * time.Second, Timeout: 30 * time.Second}, opts)
+}
+
+func TestDrain_WaitsForInFlight(t *testing.T) {
+	t.Log("Test that readiness fails during the pre-stop delay and in-flight requests complete")
+
+	d := New(Options{Delay: 50 * time.Millisecond, Timeout: time.Second})
+	check := d.Check()
+	require.NoError(t, check.Run(context.Background()))
+
+	release := make(chan struct{})
+	srv, url := startServer(t, d, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	waitInFlight(t, d, 1)
+
+	aborted := make(chan int64, 1)
+	go func() { aborted <- d.Drain(context.Background(), srv) }()
+
+	require.Eventually(t, d.Draining, time.Second, time.Millisecond)
+	require.EqualError(t, check.Run(context.Background()), "draining: 1 in-flight requests")
+
+	close(release)
+	require.Equal(t, http.StatusNoContent, <-status)
+	require.Equal(t, int64(0), <-aborted)
+}
+
+func TestDrain_AbortsAtDeadline(t *testing.T) {
+	t.Log("Test that requests still running at the deadline are counted as aborted")
+
+	d := New(Options{Timeout: 50 * time.Millisecond})
+	release := make(chan struct{})
+	defer close(release)
+	srv, url := startServer(t, d, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+
+	for i := 0; i < 2; i++ {
+		go func() {
+			if resp, err := http.Get(url); err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+	waitInFlight(t, d, 2)
+
+	start := time.Now()
+	require.Equal(t, int64(2), d.Drain(context.Background(), srv))
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestDrain_ContextCutsDelayShort(t *testing.T) {
+	d := New(Options{Delay: time.Hour, Timeout: time.Second})
+	srv, _ := startServer(t, d, func(http.ResponseWriter, *http.Request) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Equal(t, int64(0), d.Drain(ctx, srv))
+}
+
+func TestDrain_ContextDeadlineBoundsInFlight(t *testing.T) {
+	t.Log("Test that a ctx deadline earlier than Timeout ends the drain early")
+
+	d := New(Options{Timeout: time.Hour})
+	release := make(chan struct{})
+	defer close(release)
+	srv, url := startServer(t, d, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+	go func() {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	waitInFlight(t, d, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	require.Equal(t, int64(1), d.Drain(ctx, srv))
+	require.Less(t, time.Since(start), time.Second)
+}
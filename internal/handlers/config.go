@@ -1,41 +1,48 @@
 // config.go
 //
 // Exposes a safe, non-secret summary of runtime configuration for internal verification.
-// Specifically, it indicates whether FAKE_SECRET is present without revealing its value.
+// Specifically, it indicates whether FAKE_SECRET is present without revealing its value,
+// along with the generation of the configuration snapshot currently in effect.
 
 package handlers
 
 import (
 	"encoding/json"
 	"net/http"
-	"os"
 
-	"github.com/rs/zerolog/log"
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/problem"
 )
 
 type ConfigSummary struct {
-	FakeSecretPresent bool `json:"fakeSecretPresent"`
-	FakeSecretLen     int  `json:"fakeSecretLen"`
+	FakeSecretPresent bool   `json:"fakeSecretPresent"`
+	FakeSecretLen     int    `json:"fakeSecretLen"`
+	Generation        uint64 `json:"generation"`
 }
 
-// ConfigHandler handles GET /internal/config requests.
-// It returns a JSON object indicating whether FAKE_SECRET is set and its length.
-func ConfigHandler(w http.ResponseWriter, r *http.Request) {
-	v := os.Getenv("FAKE_SECRET")
+// InternalConfig handles GET /internal/config requests.
+// It returns a JSON object indicating whether FAKE_SECRET is set, its length
+// and the configuration generation.
+func (s *Server) InternalConfig(w http.ResponseWriter, r *http.Request) {
+	logger := s.requestLogger(r)
+	snap := s.deps.Config.Load()
+
+	v := snap.Secret(config.FakeSecretName)
 	present := v != ""
 	if present {
-		log.Debug().Int("fakeSecretLen", len(v)).Msg("FAKE_SECRET present")
+		logger.Debug().Int("fakeSecretLen", len(v)).Msg("FAKE_SECRET present")
 	} else {
-		log.Debug().Msg("FAKE_SECRET not set")
+		logger.Debug().Msg("FAKE_SECRET not set")
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(ConfigSummary{
 		FakeSecretPresent: present,
 		FakeSecretLen:     len(v),
+		Generation:        snap.Generation,
 	}); err != nil {
-		log.Error().Err(err).Msg("Failed to write /internal/config response")
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		logger.Error().Err(err).Msg("Failed to write /internal/config response")
+		problem.Error(w, r, http.StatusInternalServerError, "failed to write response")
 		return
 	}
 }
@@ -4,20 +4,17 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/config"
 )
 
 func TestConfigHandler_PresenceFalse(t *testing.T) {
-	prev := os.Getenv("FAKE_SECRET")
-	t.Cleanup(func() { _ = os.Setenv("FAKE_SECRET", prev) })
-	_ = os.Unsetenv("FAKE_SECRET")
-
 	r := chi.NewRouter()
-	r.Get("/internal/config", ConfigHandler)
+	r.Get("/internal/config", testServer(t, testConfig()).InternalConfig)
 
 	req, err := http.NewRequest("GET", "/internal/config", nil)
 	require.NoError(t, err)
@@ -29,15 +26,14 @@ func TestConfigHandler_PresenceFalse(t *testing.T) {
 	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
 	require.False(t, resp.FakeSecretPresent)
 	require.Equal(t, 0, resp.FakeSecretLen)
+	require.Equal(t, uint64(1), resp.Generation)
 }
 
 func TestConfigHandler_PresenceTrue(t *testing.T) {
-	prev := os.Getenv("FAKE_SECRET")
-	t.Cleanup(func() { _ = os.Setenv("FAKE_SECRET", prev) })
-	require.NoError(t, os.Setenv("FAKE_SECRET", "supersecret"))
-
 	r := chi.NewRouter()
-	r.Get("/internal/config", ConfigHandler)
+	r.Get("/internal/config", testServer(t, testConfig(func(s *config.Snapshot) {
+		s.Secrets = map[string]string{config.FakeSecretName: "supersecret"}
+	})).InternalConfig)
 
 	req, err := http.NewRequest("GET", "/internal/config", nil)
 	require.NoError(t, err)
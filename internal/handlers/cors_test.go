@@ -14,6 +14,8 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
 	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/api"
 )
 
 // TestCORSHeaders ensures that the service sets expected CORS headers.
@@ -27,7 +29,7 @@ func TestCORSHeaders(t *testing.T) {
 		AllowedHeaders: []string{"*"},
 	}))
 
-	r.Get("/healthz", HealthzHandler)
+	api.RegisterHandlers(r, testServer(t, testConfig()))
 
 	// Test OPTIONS request
 	optsReq, err := http.NewRequest("OPTIONS", "/healthz", nil)
@@ -0,0 +1,58 @@
+// docs.go
+//
+// Serves the spec itself at /openapi.yaml and /openapi.json, rendered by
+// internal/apidocs with the live version and this deployment's server URL.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/paulcapestany/toy-service/internal/api"
+	"github.com/paulcapestany/toy-service/internal/apidocs"
+)
+
+// GetOpenAPIYAML handles GET /openapi.yaml requests.
+func (s *Server) GetOpenAPIYAML(ctx context.Context, request api.GetOpenAPIYAMLRequestObject) (api.GetOpenAPIYAMLResponseObject, error) {
+	body, err := s.deps.Docs.YAML(api.HTTPRequest(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("render OpenAPI spec: %w", err)
+	}
+	return docsResponse{docs: s.deps.Docs, contentType: "application/yaml", body: body}, nil
+}
+
+// GetOpenAPIJSON handles GET /openapi.json requests.
+func (s *Server) GetOpenAPIJSON(ctx context.Context, request api.GetOpenAPIJSONRequestObject) (api.GetOpenAPIJSONResponseObject, error) {
+	body, err := s.deps.Docs.JSON(api.HTTPRequest(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("render OpenAPI spec: %w", err)
+	}
+	return docsResponse{docs: s.deps.Docs, contentType: "application/json", body: body}, nil
+}
+
+// docsResponse is a rendered spec. It is written as rendered, with the
+// caching headers of apidocs, rather than through the generated response
+// types: Vary depends on the deployment, which the spec cannot express.
+type docsResponse struct {
+	docs        *apidocs.Docs
+	contentType string
+	body        []byte
+}
+
+func (resp docsResponse) VisitGetOpenAPIYAMLResponse(w http.ResponseWriter) error {
+	return resp.write(w)
+}
+
+func (resp docsResponse) VisitGetOpenAPIJSONResponse(w http.ResponseWriter) error {
+	return resp.write(w)
+}
+
+func (resp docsResponse) write(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", resp.contentType)
+	resp.docs.SetHeaders(w.Header())
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(resp.body)
+	return err
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/api"
+)
+
+func TestOpenAPIDocuments(t *testing.T) {
+	t.Log("Test that the spec endpoints serve the whole rendered document")
+
+	r := chi.NewRouter()
+	api.RegisterHandlers(r, testServer(t, testConfig()))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://toy.example/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	require.Equal(t, "Host, X-Forwarded-Host, X-Forwarded-Proto", rec.Header().Get("Vary"))
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	require.Contains(t, doc, "components", "properties beyond those the schema lists are kept")
+	require.Equal(t, "0.0.0-test", doc["info"].(map[string]any)["version"])
+	require.Equal(t, "http://toy.example", doc["servers"].([]any)[0].(map[string]any)["url"])
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/yaml", rec.Header().Get("Content-Type"))
+	require.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	require.Equal(t, "Host, X-Forwarded-Host, X-Forwarded-Proto", rec.Header().Get("Vary"))
+	require.Contains(t, rec.Body.String(), "operationId: echo")
+}
@@ -6,75 +6,35 @@
 package handlers
 
 import (
-	"encoding/json"
-	"errors"
-	"net/http"
+	"context"
 
-	"github.com/rs/zerolog/log"
+	"github.com/paulcapestany/toy-service/internal/api"
+	"github.com/paulcapestany/toy-service/internal/problem"
 )
 
-const maxEchoBodyBytes = 1 << 20 // 1 MiB
-
-type EchoRequest struct {
-	Message string `json:"message"`
-}
-
-type EchoResponse struct {
-	Message string `json:"message"`
-	Version string `json:"version"`
-	Commit  string `json:"commit"`
-	Env     string `json:"env"`
-}
-
-// EchoHandler handles POST /echo requests.
+// Echo handles POST /echo requests.
 // It echoes back the input message, appending " [modified]", and returns
-// version, commit, and environment info.
-func EchoHandler(w http.ResponseWriter, r *http.Request) {
-	log.Debug().Msg("Handling /echo request")
-
-	var req EchoRequest
-	r.Body = http.MaxBytesReader(w, r.Body, maxEchoBodyBytes)
-	decoder := json.NewDecoder(r.Body)
-	decoder.DisallowUnknownFields()
-	if err := decoder.Decode(&req); err != nil {
-		var maxBytesErr *http.MaxBytesError
-		if errors.As(err, &maxBytesErr) {
-			log.Warn().Msg("Rejected /echo request: payload too large")
-			writeJSONError(w, http.StatusRequestEntityTooLarge, "Payload too large (max 1MiB)")
-			return
-		}
-		log.Error().Err(err).Msg("Failed to decode /echo request body")
-		writeJSONError(w, http.StatusBadRequest, "Invalid input")
-		return
+// version, commit, and environment info. Malformed and oversized bodies are
+// rejected while decoding (see api.RegisterHandlers); the request body size
+// is capped by the bodylimit middleware.
+func (s *Server) Echo(ctx context.Context, request api.EchoRequestObject) (api.EchoResponseObject, error) {
+	logger := s.logger(ctx)
+	logger.Debug().Msg("Handling /echo request")
+
+	if request.Body.Message == "" {
+		return nil, problem.Validation("Invalid input",
+			problem.FieldError{Field: "message", Detail: "must not be empty"})
 	}
 
-	if req.Message == "" {
-		writeJSONError(w, http.StatusBadRequest, "Invalid input")
-		return
-	}
-
-	cfg := LoadEnvConfig()
-
-	resp := EchoResponse{
-		Message: req.Message + " [modified]",
-		Version: cfg.Version,
-		Commit:  cfg.GitCommit,
-		Env:     cfg.Env,
-	}
+	snap := s.deps.Config.Load()
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Error().Err(err).Msg("Failed to write /echo response")
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+	resp := api.EchoResponse{
+		Message: request.Body.Message + " [modified]",
+		Version: s.deps.Build.Version,
+		Commit:  s.deps.Build.Commit,
+		Env:     snap.Env,
 	}
 
-	log.Debug().Msg("/echo response successfully returned")
-}
-
-// writeJSONError writes a JSON error payload with the provided status code.
-func writeJSONError(w http.ResponseWriter, code int, msg string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+	logger.Debug().Msg("/echo response successfully returned")
+	return api.Echo200JSONResponse(resp), nil
 }
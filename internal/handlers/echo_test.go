@@ -10,13 +10,19 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/api"
+	"github.com/paulcapestany/toy-service/internal/bodylimit"
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/problem"
+	"github.com/paulcapestany/toy-service/internal/requestid"
 )
 
 func TestEchoHandler(t *testing.T) {
 	t.Log("Test that /echo returns a modified message and service metadata")
 
 	r := chi.NewRouter()
-	r.Post("/echo", EchoHandler)
+	api.RegisterHandlers(r, testServer(t, testConfig()))
 
 	reqBody := `{"message":"Hello"}`
 	req, err := http.NewRequest("POST", "/echo", bytes.NewBuffer([]byte(reqBody)))
@@ -26,7 +32,7 @@ func TestEchoHandler(t *testing.T) {
 	r.ServeHTTP(w, req)
 	require.Equal(t, http.StatusOK, w.Code)
 
-	var resp EchoResponse
+	var resp api.EchoResponse
 	err = json.Unmarshal(w.Body.Bytes(), &resp)
 	require.NoError(t, err)
 
@@ -37,10 +43,10 @@ func TestEchoHandler(t *testing.T) {
 }
 
 func TestEchoHandler_EmptyMessage(t *testing.T) {
-	t.Log("Test that /echo rejects empty message payloads with JSON error")
+	t.Log("Test that /echo rejects empty message payloads with a validation problem")
 
 	r := chi.NewRouter()
-	r.Post("/echo", EchoHandler)
+	api.RegisterHandlers(r, testServer(t, testConfig()))
 
 	reqBody := `{"message":""}`
 	req, err := http.NewRequest("POST", "/echo", bytes.NewBuffer([]byte(reqBody)))
@@ -49,19 +55,22 @@ func TestEchoHandler_EmptyMessage(t *testing.T) {
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 	require.Equal(t, http.StatusBadRequest, w.Code)
-	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
 
-	var resp map[string]string
+	var resp problem.Problem
 	err = json.Unmarshal(w.Body.Bytes(), &resp)
 	require.NoError(t, err)
-	require.Equal(t, "Invalid input", resp["error"])
+	require.Equal(t, problem.TypeValidation, resp.Type)
+	require.Equal(t, "Invalid input", resp.Detail)
+	require.Equal(t, "/echo", resp.Instance)
+	require.Equal(t, []problem.FieldError{{Field: "message", Detail: "must not be empty"}}, resp.Errors)
 }
 
 func TestEchoHandler_UnknownField(t *testing.T) {
 	t.Log("Test that /echo rejects payloads with unknown fields")
 
 	r := chi.NewRouter()
-	r.Post("/echo", EchoHandler)
+	api.RegisterHandlers(r, testServer(t, testConfig()))
 
 	reqBody := `{"message":"hi","unexpected":"value"}`
 	req, err := http.NewRequest("POST", "/echo", bytes.NewBuffer([]byte(reqBody)))
@@ -70,32 +79,88 @@ func TestEchoHandler_UnknownField(t *testing.T) {
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 	require.Equal(t, http.StatusBadRequest, w.Code)
-	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
 
-	var resp map[string]string
+	var resp problem.Problem
 	err = json.Unmarshal(w.Body.Bytes(), &resp)
 	require.NoError(t, err)
-	require.Equal(t, "Invalid input", resp["error"])
+	require.Equal(t, http.StatusBadRequest, resp.Status)
+	require.Equal(t, "Invalid input", resp.Detail)
 }
 
 func TestEchoHandler_TooLarge(t *testing.T) {
 	t.Log("Test that /echo rejects payloads larger than the configured limit")
 
+	for _, tc := range []struct {
+		limits config.Limits
+		want   string
+	}{
+		{config.Limits{MaxBodyBytes: config.MiB}, "Payload too large (max 1MiB)"},
+		{config.Limits{MaxBodyBytes: config.MiB, BodyLimits: config.BodyLimits{"/echo": 64 * config.KiB}}, "Payload too large (max 64KiB)"},
+	} {
+		r := chi.NewRouter()
+		r.Use(bodylimit.New(bodylimit.OptionsFromConfig(tc.limits)))
+		api.RegisterHandlers(r, testServer(t, testConfig()))
+
+		oversized := strings.Repeat("a", int(tc.limits.BodyLimit("/echo"))+1)
+		reqBody := `{"message":"` + oversized + `"}` // single field with huge value
+		req, err := http.NewRequest("POST", "/echo", bytes.NewBufferString(reqBody))
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		require.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+		var resp problem.Problem
+		err = json.Unmarshal(w.Body.Bytes(), &resp)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusText(http.StatusRequestEntityTooLarge), resp.Title)
+		require.Equal(t, tc.want, resp.Detail)
+	}
+}
+
+func TestEchoHandler_ErrorIncludesRequestID(t *testing.T) {
+	t.Log("Test that /echo errors carry the request ID assigned by the middleware")
+
+	r := chi.NewRouter()
+	r.Use(requestid.Middleware)
+	api.RegisterHandlers(r, testServer(t, testConfig()))
+
+	req, err := http.NewRequest("POST", "/echo", bytes.NewBufferString(`{"message":""}`))
+	require.NoError(t, err)
+	req.Header.Set(requestid.Header, "toy-web-42")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, "toy-web-42", w.Header().Get(requestid.Header))
+
+	var resp problem.Problem
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, "toy-web-42", resp.RequestID)
+}
+
+func TestEchoHandler_LegacyError(t *testing.T) {
+	t.Log("Test that clients accepting only application/json still get the legacy error body")
+
 	r := chi.NewRouter()
-	r.Post("/echo", EchoHandler)
+	r.Use(requestid.Middleware)
+	api.RegisterHandlers(r, testServer(t, testConfig()))
 
-	oversized := strings.Repeat("a", maxEchoBodyBytes+1)
-	reqBody := `{"message":"` + oversized + `"}` // single field with huge value
-	req, err := http.NewRequest("POST", "/echo", bytes.NewBufferString(reqBody))
+	req, err := http.NewRequest("POST", "/echo", bytes.NewBufferString(`{"message":""}`))
 	require.NoError(t, err)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(requestid.Header, "toy-web-42")
 
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
-	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	require.Equal(t, http.StatusBadRequest, w.Code)
 	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
 
 	var resp map[string]string
 	err = json.Unmarshal(w.Body.Bytes(), &resp)
 	require.NoError(t, err)
-	require.Equal(t, "Payload too large (max 1MiB)", resp["error"])
+	require.Equal(t, map[string]string{"error": "Invalid input", "requestId": "toy-web-42"}, resp)
 }
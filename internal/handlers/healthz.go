@@ -2,32 +2,25 @@
 //
 // The healthz handler provides a simple health check endpoint.
 // It returns a static JSON response {"status":"ok"} if the server is running.
-// This endpoint is used for readiness/liveness probes and initial verification
-// that the service is functioning properly.
+// It is kept for existing probes and smoke tests; the check-backed /livez,
+// /readyz and /startupz probes are in probes.go.
 
 package handlers
 
 import (
-	"encoding/json"
-	"net/http"
+	"context"
 
-	"github.com/rs/zerolog/log"
+	"github.com/paulcapestany/toy-service/internal/api"
 )
 
-// HealthzHandler handles GET /healthz requests.
+// GetHealthz handles GET /healthz requests.
 // It returns a JSON object indicating server health status.
-func HealthzHandler(w http.ResponseWriter, r *http.Request) {
-	log.Debug().Msg("Handling /healthz request")
+func (s *Server) GetHealthz(ctx context.Context, request api.GetHealthzRequestObject) (api.GetHealthzResponseObject, error) {
+	logger := s.logger(ctx)
+	logger.Debug().Msg("Handling /healthz request")
 
-	resp := map[string]string{"status": "ok"}
+	resp := api.HealthResponse{Status: "ok"}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Cache-Control", "no-store")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Error().Err(err).Msg("Failed to write /healthz response")
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
-	}
-
-	log.Debug().Msg("/healthz response successfully returned")
+	logger.Debug().Msg("/healthz response successfully returned")
+	return api.GetHealthz200JSONResponse{Body: resp, Headers: api.GetHealthz200ResponseHeaders{CacheControl: noStore}}, nil
 }
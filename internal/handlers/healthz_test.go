@@ -13,13 +13,15 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/api"
 )
 
 func TestHealthzHandler(t *testing.T) {
 	t.Log("Test that /healthz returns a 200 and {'status':'ok'}")
 
 	r := chi.NewRouter()
-	r.Get("/healthz", HealthzHandler)
+	api.RegisterHandlers(r, testServer(t, testConfig()))
 
 	req, err := http.NewRequest("GET", "/healthz", nil)
 	require.NoError(t, err)
@@ -1,56 +1,45 @@
 // info.go
 //
-// The info handler returns service metadata based on environment variables
-// and hardcoded defaults.
+// The info handler returns service metadata from the current configuration
+// snapshot, including its generation number, and the time since the server
+// was created. The reported log verbosity is the live level from
+// Deps.Level, which may differ from LOG_VERBOSITY after a runtime change.
 
 package handlers
 
 import (
-	"encoding/json"
-	"net/http"
-	"os"
+	"context"
+	"time"
 
-	"github.com/rs/zerolog/log"
+	"github.com/paulcapestany/toy-service/internal/api"
+	"github.com/paulcapestany/toy-service/internal/config"
 )
 
-// InfoHandler handles GET /info requests.
+// GetInfo handles GET /info requests.
 // It returns details about the service configuration and runtime environment.
-func InfoHandler(w http.ResponseWriter, r *http.Request) {
-	log.Debug().Msg("Handling /info request")
-
-	cfg := LoadEnvConfig()
-
-	secretVal, secretSet := os.LookupEnv("FAKE_SECRET")
-	fakeSecretPresent := secretSet && secretVal != ""
-
-	resp := struct {
-		Name              string `json:"name"`
-		Version           string `json:"version"`
-		Env               string `json:"env"`
-		LogVerbosity      string `json:"logVerbosity"`
-		FakeSecretPresent bool   `json:"fakeSecretPresent"`
-		FakeSecretLength  int    `json:"fakeSecretLength,omitempty"`
-		Commit            string `json:"commit"`
-	}{
-		Name:              cfg.Name,
-		Version:           cfg.Version,
-		Env:               cfg.Env,
-		LogVerbosity:      cfg.LogVerbosity,
+func (s *Server) GetInfo(ctx context.Context, request api.GetInfoRequestObject) (api.GetInfoResponseObject, error) {
+	logger := s.logger(ctx)
+	logger.Debug().Msg("Handling /info request")
+
+	snap := s.deps.Config.Load()
+	fakeSecret := snap.Secret(config.FakeSecretName)
+	fakeSecretPresent := fakeSecret != ""
+
+	resp := api.InfoResponse{
+		Name:              s.deps.Build.Name,
+		Version:           s.deps.Build.Version,
+		Env:               snap.Env,
+		LogVerbosity:      s.deps.Level().String(),
 		FakeSecretPresent: fakeSecretPresent,
-		Commit:            cfg.GitCommit,
+		Commit:            s.deps.Build.Commit,
+		ConfigGeneration:  int(snap.Generation),
+		UptimeSeconds:     int(s.deps.Clock().Sub(s.started) / time.Second),
 	}
 
 	if fakeSecretPresent {
-		resp.FakeSecretLength = len(secretVal)
+		resp.FakeSecretLength = len(fakeSecret)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Cache-Control", "no-store")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Error().Err(err).Msg("Failed to write /info response")
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
-	}
-
-	log.Debug().Msg("/info response successfully returned")
+	logger.Debug().Msg("/info response successfully returned")
+	return api.GetInfo200JSONResponse{Body: resp, Headers: api.GetInfo200ResponseHeaders{CacheControl: noStore}}, nil
 }
@@ -8,13 +8,16 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/api"
+	"github.com/paulcapestany/toy-service/internal/config"
 )
 
 func TestInfoHandler(t *testing.T) {
 	t.Log("Test that /info returns service metadata without exposing secrets")
 
 	r := chi.NewRouter()
-	r.Get("/info", InfoHandler)
+	api.RegisterHandlers(r, testServer(t, testConfig()))
 
 	req, err := http.NewRequest("GET", "/info", nil)
 	require.NoError(t, err)
@@ -32,6 +35,7 @@ func TestInfoHandler(t *testing.T) {
 		FakeSecretPresent bool   `json:"fakeSecretPresent"`
 		FakeSecretLength  int    `json:"fakeSecretLength"`
 		Commit            string `json:"commit"`
+		ConfigGeneration  uint64 `json:"configGeneration"`
 	}
 	err = json.Unmarshal(w.Body.Bytes(), &resp)
 	require.NoError(t, err)
@@ -43,6 +47,7 @@ func TestInfoHandler(t *testing.T) {
 	require.False(t, resp.FakeSecretPresent)
 	require.Equal(t, 0, resp.FakeSecretLength)
 	require.NotEmpty(t, resp.Commit)
+	require.Equal(t, uint64(1), resp.ConfigGeneration)
 }
 
 func TestInfoHandlerWithSecret(t *testing.T) {
@@ -51,9 +56,9 @@ func TestInfoHandlerWithSecret(t *testing.T) {
 	const secret = "super-secret"
 
 	r := chi.NewRouter()
-	r.Get("/info", InfoHandler)
-
-	t.Setenv("FAKE_SECRET", secret)
+	api.RegisterHandlers(r, testServer(t, testConfig(func(s *config.Snapshot) {
+		s.Secrets = map[string]string{config.FakeSecretName: secret}
+	})))
 
 	req, err := http.NewRequest("GET", "/info", nil)
 	require.NoError(t, err)
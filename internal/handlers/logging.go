@@ -0,0 +1,27 @@
+// logging.go
+//
+// Provides access to the request-scoped logger so every line emitted by the
+// handlers carries the request ID assigned by the requestid middleware.
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	"github.com/paulcapestany/toy-service/internal/logctx"
+)
+
+// requestLogger returns the logger stored in the request context; see
+// logger.
+func (s *Server) requestLogger(r *http.Request) *zerolog.Logger {
+	return s.logger(r.Context())
+}
+
+// logger returns the logger stored in ctx, falling back to Deps.Logger when
+// the handler runs without the requestid middleware (e.g. in unit tests).
+func (s *Server) logger(ctx context.Context) *zerolog.Logger {
+	return logctx.Or(ctx, s.deps.Logger)
+}
@@ -0,0 +1,65 @@
+// loglevel.go
+//
+// Admin endpoint for inspecting and changing the log level at runtime.
+// Authorization is applied by the router (see internal/auth): the endpoint
+// requires the ADMIN_TOKEN bearer token and is disabled when none is set.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/paulcapestany/toy-service/internal/loglevel"
+	"github.com/paulcapestany/toy-service/internal/problem"
+)
+
+// LogLevelRequest is the body accepted by PUT /-/log-level.
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// LogLevelResponse reports the active level and, after a change, the previous one.
+type LogLevelResponse struct {
+	Level    string `json:"level"`
+	Previous string `json:"previous,omitempty"`
+}
+
+// LogLevel handles GET and PUT /-/log-level requests.
+// GET returns the active level; PUT with {"level":"debug"} changes it through
+// Deps.SetLevel. With the default setter the level is process-wide: it
+// applies to every Server in the process.
+func (s *Server) LogLevel(w http.ResponseWriter, r *http.Request) {
+	logger := s.requestLogger(r)
+
+	resp := LogLevelResponse{Level: s.deps.Level().String()}
+
+	if r.Method == http.MethodPut {
+		var req LogLevelRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			problem.Error(w, r, http.StatusBadRequest, "Invalid input")
+			return
+		}
+		level, err := loglevel.Parse(req.Level)
+		if err != nil {
+			problem.Write(w, r, problem.Validation(err.Error(),
+				problem.FieldError{Field: "level", Detail: err.Error()}))
+			return
+		}
+
+		prev := s.deps.SetLevel(level)
+		resp = LogLevelResponse{Level: level.String(), Previous: prev.String()}
+		// Log without a level so the change is recorded even when raising to error.
+		logger.Log().Str("from", prev.String()).Str("to", level.String()).Msg("Log level changed via /-/log-level")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error().Err(err).Msg("Failed to write /-/log-level response")
+		problem.Error(w, r, http.StatusInternalServerError, "failed to write response")
+		return
+	}
+}
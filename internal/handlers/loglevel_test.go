@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/api"
+	"github.com/paulcapestany/toy-service/internal/auth"
+	"github.com/paulcapestany/toy-service/internal/problem"
+)
+
+func newLogLevelRouter(t *testing.T, token string) *chi.Mux {
+	t.Helper()
+	prev := zerolog.GlobalLevel()
+	t.Cleanup(func() { zerolog.SetGlobalLevel(prev) })
+
+	admin := auth.Policy{Name: "admin"}
+	if token != "" {
+		admin.Tokens = []string{token}
+	}
+	s := testServer(t, testConfig())
+	r := chi.NewRouter()
+	r.With(auth.Require(admin)).Get("/-/log-level", s.LogLevel)
+	r.With(auth.Require(admin)).Put("/-/log-level", s.LogLevel)
+	api.RegisterHandlers(r, s)
+	return r
+}
+
+func TestLogLevelHandler_DisabledWithoutToken(t *testing.T) {
+	r := newLogLevelRouter(t, "")
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/-/log-level", nil))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestLogLevelHandler_RejectsBadToken(t *testing.T) {
+	r := newLogLevelRouter(t, "s3cret")
+
+	req := httptest.NewRequest(http.MethodPut, "/-/log-level", strings.NewReader(`{"level":"debug"}`))
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+	require.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+}
+
+func TestLogLevelHandler_ChangesLevel(t *testing.T) {
+	t.Log("Test that an authenticated PUT changes the live level and /info reflects it")
+
+	r := newLogLevelRouter(t, "s3cret")
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+
+	req := httptest.NewRequest(http.MethodPut, "/-/log-level", strings.NewReader(`{"level":"debug"}`))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp LogLevelResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "debug", resp.Level)
+	require.Equal(t, "info", resp.Previous)
+	require.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/info", nil))
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+	require.Equal(t, "debug", info["logVerbosity"])
+}
+
+func TestLogLevelHandler_RejectsUnknownLevel(t *testing.T) {
+	r := newLogLevelRouter(t, "s3cret")
+
+	req := httptest.NewRequest(http.MethodPut, "/-/log-level", strings.NewReader(`{"level":"loud"}`))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
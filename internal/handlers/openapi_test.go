@@ -5,26 +5,27 @@ import (
 	"context"
 	"encoding/json"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/http/httptest"
-	"path/filepath"
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/api"
+	"github.com/paulcapestany/toy-service/spec"
 )
 
-// loadOpenAPISpec loads and parses the OpenAPI spec from the local spec/openapi.yaml file.
+// loadOpenAPISpec parses the embedded spec/openapi.yaml, the same document
+// the contract middleware validates against.
 func loadOpenAPISpec(t *testing.T) *openapi3.T {
 	t.Helper()
 
-	specPath := filepath.Join("..", "..", "spec", "openapi.yaml")
 	loader := openapi3.NewLoader()
-	loader.IsExternalRefsAllowed = true
-
-	swagger, err := loader.LoadFromFile(specPath)
+	swagger, err := loader.LoadFromData(spec.OpenAPI)
 	require.NoError(t, err, "Failed to load OpenAPI spec")
 
 	// Validate the spec to ensure correctness (including examples).
@@ -35,12 +36,10 @@ func loadOpenAPISpec(t *testing.T) *openapi3.T {
 }
 
 // newTestServer starts a httptest server with the handlers registered.
-func newTestServer() *httptest.Server {
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
 	r := chi.NewRouter()
-	r.Get("/healthz", HealthzHandler)
-	r.Post("/echo", EchoHandler)
-	r.Get("/info", InfoHandler)
-	r.Get("/version", VersionHandler)
+	api.RegisterHandlers(r, testServer(t, testConfig()))
 
 	return httptest.NewServer(r)
 }
@@ -49,7 +48,7 @@ func TestOpenAPIConformance(t *testing.T) {
 	log.Debug().Msg("Starting OpenAPI conformance tests")
 
 	swagger := loadOpenAPISpec(t)
-	server := newTestServer()
+	server := newTestServer(t)
 	defer server.Close()
 
 	healthzPath := "/healthz"
@@ -67,7 +66,7 @@ func TestOpenAPIConformance(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, http.StatusOK, resp.StatusCode)
 
-		validateResponse(t, swagger, "get", healthzPath, resp.StatusCode, body)
+		validateResponse(t, swagger, "get", healthzPath, resp.StatusCode, resp.Header.Get("Content-Type"), body)
 	})
 
 	// 2. Test /info
@@ -80,7 +79,7 @@ func TestOpenAPIConformance(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, http.StatusOK, resp.StatusCode)
 
-		validateResponse(t, swagger, "get", infoPath, resp.StatusCode, body)
+		validateResponse(t, swagger, "get", infoPath, resp.StatusCode, resp.Header.Get("Content-Type"), body)
 	})
 
 	// 3. Test /version
@@ -93,7 +92,7 @@ func TestOpenAPIConformance(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, http.StatusOK, resp.StatusCode)
 
-		validateResponse(t, swagger, "get", versionPath, resp.StatusCode, body)
+		validateResponse(t, swagger, "get", versionPath, resp.StatusCode, resp.Header.Get("Content-Type"), body)
 	})
 
 	// 4. Test /echo with valid input
@@ -107,7 +106,7 @@ func TestOpenAPIConformance(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, http.StatusOK, resp.StatusCode)
 
-		validateResponse(t, swagger, "post", echoPath, resp.StatusCode, body)
+		validateResponse(t, swagger, "post", echoPath, resp.StatusCode, resp.Header.Get("Content-Type"), body)
 	})
 
 	// 5. Test /echo with invalid input (missing required "message" field)
@@ -121,13 +120,30 @@ func TestOpenAPIConformance(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
 
-		validateResponse(t, swagger, "post", echoPath, resp.StatusCode, body)
+		validateResponse(t, swagger, "post", echoPath, resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	})
+
+	// 6. Test /echo errors in the legacy shape for clients accepting only application/json
+	t.Run("POST /echo with invalid input (legacy client)", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+echoPath, bytes.NewBufferString(`{"message":""}`))
+		require.NoError(t, err)
+		req.Header.Set("Accept", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+		validateResponse(t, swagger, "post", echoPath, resp.StatusCode, resp.Header.Get("Content-Type"), body)
 	})
 }
 
 // validateResponse uses the loaded swagger and the provided method/path/status to look up the expected schema.
 // It then validates the response body against that schema.
-func validateResponse(t *testing.T, swagger *openapi3.T, method, path string, statusCode int, body []byte) {
+func validateResponse(t *testing.T, swagger *openapi3.T, method, path string, statusCode int, contentType string, body []byte) {
 	t.Helper()
 
 	// Use Paths.Find to locate the PathItem associated with this path.
@@ -141,18 +157,20 @@ func validateResponse(t *testing.T, swagger *openapi3.T, method, path string, st
 	require.NotNil(t, responseRef, "No response defined for %d on %s %s", statusCode, method, path)
 	require.NotNil(t, responseRef.Value, "Response value is nil for %d on %s %s", statusCode, method, path)
 
-	// Check if there's a JSON schema defined
-	jsonContent, hasJSON := responseRef.Value.Content["application/json"]
+	// Check if there's a schema defined for the served media type
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err, "Invalid Content-Type %q for %d on %s %s", contentType, statusCode, method, path)
+	jsonContent, hasJSON := responseRef.Value.Content[mediaType]
 	if !hasJSON {
-		t.Fatalf("No application/json response schema found for %d on %s %s", statusCode, method, path)
+		t.Fatalf("No %s response schema found for %d on %s %s", mediaType, statusCode, method, path)
 	}
 
 	schemaRef := jsonContent.Schema
-	require.NotNil(t, schemaRef, "No schema defined for application/json in %d response on %s %s", statusCode, method, path)
+	require.NotNil(t, schemaRef, "No schema defined for %s in %d response on %s %s", mediaType, statusCode, method, path)
 
 	// Parse JSON response into interface{}
 	var data interface{}
-	err := json.Unmarshal(body, &data)
+	err = json.Unmarshal(body, &data)
 	require.NoError(t, err, "Failed to unmarshal response body to JSON: %s", string(body))
 
 	// Validate data against the schema
@@ -0,0 +1,61 @@
+// probes.go
+//
+// The /livez, /readyz and /startupz probes run the checks registered with
+// internal/health for their probe. They answer 200 with {"status":"ok"} or
+// 503 with {"status":"fail"}; adding ?verbose lists every check with its
+// status, error and latency.
+
+package handlers
+
+import (
+	"context"
+
+	"github.com/paulcapestany/toy-service/internal/api"
+	"github.com/paulcapestany/toy-service/internal/health"
+)
+
+// GetLivez handles GET /livez requests.
+func (s *Server) GetLivez(ctx context.Context, request api.GetLivezRequestObject) (api.GetLivezResponseObject, error) {
+	report, ok := s.probe(ctx, health.Liveness, request.Params.Verbose != nil)
+	if !ok {
+		return api.GetLivez503JSONResponse{Body: report, Headers: api.GetLivez503ResponseHeaders{CacheControl: noStore}}, nil
+	}
+	return api.GetLivez200JSONResponse{Body: report, Headers: api.GetLivez200ResponseHeaders{CacheControl: noStore}}, nil
+}
+
+// GetReadyz handles GET /readyz requests.
+func (s *Server) GetReadyz(ctx context.Context, request api.GetReadyzRequestObject) (api.GetReadyzResponseObject, error) {
+	report, ok := s.probe(ctx, health.Readiness, request.Params.Verbose != nil)
+	if !ok {
+		return api.GetReadyz503JSONResponse{Body: report, Headers: api.GetReadyz503ResponseHeaders{CacheControl: noStore}}, nil
+	}
+	return api.GetReadyz200JSONResponse{Body: report, Headers: api.GetReadyz200ResponseHeaders{CacheControl: noStore}}, nil
+}
+
+// GetStartupz handles GET /startupz requests.
+func (s *Server) GetStartupz(ctx context.Context, request api.GetStartupzRequestObject) (api.GetStartupzResponseObject, error) {
+	report, ok := s.probe(ctx, health.Startup, request.Params.Verbose != nil)
+	if !ok {
+		return api.GetStartupz503JSONResponse{Body: report, Headers: api.GetStartupz503ResponseHeaders{CacheControl: noStore}}, nil
+	}
+	return api.GetStartupz200JSONResponse{Body: report, Headers: api.GetStartupz200ResponseHeaders{CacheControl: noStore}}, nil
+}
+
+// probe runs probe and reports whether it passed. Per-check results are
+// only included when verbose.
+func (s *Server) probe(ctx context.Context, probe health.Probe, verbose bool) (api.HealthReport, bool) {
+	report := s.deps.Probes.Run(ctx, probe)
+	out := api.HealthReport{Status: api.HealthReportStatus(report.Status)}
+	if verbose {
+		for _, c := range report.Checks {
+			out.Checks = append(out.Checks, api.HealthCheckResult{
+				Name:       c.Name,
+				Status:     api.HealthCheckResultStatus(c.Status),
+				Error:      c.Error,
+				DurationMs: c.Duration,
+				Cached:     c.Cached,
+			})
+		}
+	}
+	return out, report.Status == health.StatusOK
+}
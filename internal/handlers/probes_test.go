@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/api"
+	"github.com/paulcapestany/toy-service/internal/health"
+)
+
+func TestProbes(t *testing.T) {
+	s := testServer(t, testConfig())
+	ok := func(context.Context) error { return nil }
+	require.NoError(t, s.deps.Probes.Register(health.Check{Name: "config", Probes: health.Liveness | health.Readiness, Run: ok}))
+	require.NoError(t, s.deps.Probes.Register(health.Check{Name: "secrets", Probes: health.Readiness, Run: func(context.Context) error {
+		return errors.New("missing FAKE_SECRET")
+	}}))
+	r := chi.NewRouter()
+	api.RegisterHandlers(r, s)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	t.Log("Test that a passing probe returns 200 with a bare status")
+	rec := get("/livez")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	require.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+
+	t.Log("Test that a failing probe returns 503")
+	rec = get("/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.JSONEq(t, `{"status":"fail"}`, rec.Body.String())
+
+	t.Log("Test that ?verbose lists each check")
+	rec = get("/readyz?verbose")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var report api.HealthReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	require.Len(t, report.Checks, 2)
+	require.Equal(t, "secrets", report.Checks[1].Name)
+	require.Equal(t, api.HealthCheckResultStatusFail, report.Checks[1].Status)
+	require.Equal(t, "missing FAKE_SECRET", report.Checks[1].Error)
+
+	t.Log("Test that a probe without checks passes")
+	rec = get("/startupz?verbose")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
@@ -2,56 +2,62 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
 
-	"github.com/rs/zerolog/log"
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/problem"
+	"github.com/paulcapestany/toy-service/internal/secrets"
 )
 
-// ReloadHandler reads the FAKE_SECRET value from a mounted secret file and updates
-// the process environment so handlers that read os.Getenv can see the new value.
+// Reload handles POST /-/reload requests. It re-reads every secret declared
+// in the schema of Deps.Reloader from the mounted secret directory and
+// atomically publishes them as a new configuration snapshot.
 //
-// By default it looks under /etc/backend-secret/FAKE_SECRET, but the base path can
-// be overridden via SECRET_FILE_DIR. This pairs with the Helm chart which mounts
-// the Secret at /etc/backend-secret by default.
-
-// reloadResponse is returned on successful reloads.
-type reloadResponse struct {
-	Status        string `json:"status"`
-	FakeSecretLen int    `json:"fakeSecretLen"`
-}
-
-func ReloadHandler(w http.ResponseWriter, r *http.Request) {
-	base := os.Getenv("SECRET_FILE_DIR")
-	if base == "" {
-		base = "/etc/backend-secret"
-	}
-	path := filepath.Join(base, "FAKE_SECRET")
-
-	data, err := os.ReadFile(path)
+// The directory defaults to /etc/backend-secret and can be overridden via
+// SECRET_FILE_DIR. This pairs with the Helm chart which mounts the Secret at
+// /etc/backend-secret by default. Reloads are all-or-nothing: if a required
+// key is missing or any file cannot be read, nothing is applied.
+func (s *Server) Reload(w http.ResponseWriter, r *http.Request) {
+	logger := s.requestLogger(r)
+
+	result, err := s.deps.Reloader.Reload(secrets.TriggerWebhook)
 	if err != nil {
-		log.Error().Err(err).Str("path", path).Msg("failed reading secret file")
-		writeJSONError(w, http.StatusInternalServerError, "failed to read secret file")
+		var missing *secrets.MissingError
+		if errors.As(err, &missing) {
+			logger.Error().Strs("missing", missing.Names).Msg("secret reload rejected: required secrets missing")
+			p := problem.New(http.StatusUnprocessableEntity, err.Error())
+			for _, name := range missing.Names {
+				p.Errors = append(p.Errors, problem.FieldError{Field: name, Detail: "required secret is missing"})
+			}
+			problem.Write(w, r, p)
+			return
+		}
+		logger.Error().Err(err).Msg("failed reading secret files")
+		problem.Error(w, r, http.StatusInternalServerError, "failed to read secret files")
 		return
 	}
 
-	// Trim trailing newlines/whitespace if present (kube Secret keys are raw bytes)
-	val := strings.TrimRight(string(data), "\r\n")
-	// Update process env so subsequent os.Getenv reads see the new value
-	if err := os.Setenv("FAKE_SECRET", val); err != nil {
-		log.Error().Err(err).Msg("failed setting env")
-		writeJSONError(w, http.StatusInternalServerError, "failed setting env")
-		return
-	}
-
-	log.Info().Int("fakeSecretLen", len(val)).Msg("FAKE_SECRET reloaded from file")
+	// KeyStatus carries only presence/length metadata, never values.
+	logger.Info().
+		Uint64("generation", result.Generation).
+		Interface("secrets", result.Secrets).
+		Strs("ignored", result.Ignored).
+		Msg("secrets reloaded from files")
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(reloadResponse{
 		Status:        "ok",
-		FakeSecretLen: len(val),
+		FakeSecretLen: result.Secrets[config.FakeSecretName].Length,
+		Result:        result,
 	})
 }
+
+// reloadResponse is returned on successful reloads. fakeSecretLen is kept for
+// clients predating per-key statuses.
+type reloadResponse struct {
+	Status        string `json:"status"`
+	FakeSecretLen int    `json:"fakeSecretLen"`
+	secrets.Result
+}
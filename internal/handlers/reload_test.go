@@ -10,12 +10,18 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/problem"
+	"github.com/paulcapestany/toy-service/internal/secrets"
 )
 
 func TestReloadHandler_Success(t *testing.T) {
 	dir := t.TempDir()
-	t.Setenv("SECRET_FILE_DIR", dir+string(os.PathSeparator))
-	t.Setenv("FAKE_SECRET", "old-secret")
+	store := testConfig(func(s *config.Snapshot) {
+		s.SecretDir = dir + string(os.PathSeparator)
+		s.Secrets = map[string]string{config.FakeSecretName: "old-secret"}
+	})
 
 	secretValue := "new-secret\n"
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "FAKE_SECRET"), []byte(secretValue), 0o600))
@@ -23,7 +29,7 @@ func TestReloadHandler_Success(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/-/reload", nil)
 	rr := httptest.NewRecorder()
 
-	ReloadHandler(rr, req)
+	NewServer(Deps{Config: store, Reloader: secrets.NewReloader(store, nil)}).Reload(rr, req)
 
 	require.Equal(t, http.StatusOK, rr.Code)
 	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
@@ -33,24 +39,55 @@ func TestReloadHandler_Success(t *testing.T) {
 
 	assert.Equal(t, "ok", resp.Status)
 	assert.Equal(t, len("new-secret"), resp.FakeSecretLen)
-	assert.Equal(t, "new-secret", os.Getenv("FAKE_SECRET"))
+	assert.Equal(t, uint64(2), resp.Generation)
+	assert.Equal(t, secrets.KeyStatus{Present: true, Length: len("new-secret"), Changed: true}, resp.Secrets[config.FakeSecretName])
+	assert.Equal(t, "new-secret", store.Load().Secret(config.FakeSecretName))
+	assert.Equal(t, uint64(2), store.Load().Generation)
 }
 
 func TestReloadHandler_ReadFailure(t *testing.T) {
-	t.Setenv("SECRET_FILE_DIR", filepath.Join(t.TempDir(), "missing"))
-	t.Setenv("FAKE_SECRET", "should-stay")
+	store := testConfig(func(s *config.Snapshot) {
+		s.SecretDir = filepath.Join(t.TempDir(), "missing")
+		s.Secrets = map[string]string{config.FakeSecretName: "should-stay"}
+	})
 
 	req := httptest.NewRequest(http.MethodPost, "/-/reload", nil)
 	rr := httptest.NewRecorder()
 
-	ReloadHandler(rr, req)
+	NewServer(Deps{Config: store, Reloader: secrets.NewReloader(store, nil)}).Reload(rr, req)
 
 	require.Equal(t, http.StatusInternalServerError, rr.Code)
-	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	require.Equal(t, problem.ContentType, rr.Header().Get("Content-Type"))
+
+	var body problem.Problem
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "failed to read secret files", body.Detail)
+
+	assert.Equal(t, "should-stay", store.Load().Secret(config.FakeSecretName))
+	assert.Equal(t, uint64(1), store.Load().Generation)
+}
+
+func TestReloadHandler_MissingRequiredIsAllOrNothing(t *testing.T) {
+	t.Log("Test that a half-written Secret is rejected and nothing is applied")
+
+	dir := t.TempDir()
+	store := testConfig(func(s *config.Snapshot) {
+		s.SecretDir = dir
+		s.Secrets = map[string]string{config.FakeSecretName: "old", "API_TOKEN": "old-token"}
+	})
+	schema, err := secrets.ParseSchema("FAKE_SECRET,API_TOKEN")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "FAKE_SECRET"), []byte("new"), 0o600))
+
+	rr := httptest.NewRecorder()
+	NewServer(Deps{Config: store, Reloader: secrets.NewReloader(store, schema)}).Reload(rr, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
 
-	var body map[string]string
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	var body problem.Problem
 	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
-	assert.Equal(t, "failed to read secret file", body["error"])
+	assert.Equal(t, "missing required secrets: API_TOKEN", body.Detail)
+	assert.Equal(t, []problem.FieldError{{Field: "API_TOKEN", Detail: "required secret is missing"}}, body.Errors)
 
-	assert.Equal(t, "should-stay", os.Getenv("FAKE_SECRET"))
+	assert.Equal(t, "old", store.Load().Secret(config.FakeSecretName))
+	assert.Equal(t, uint64(1), store.Load().Generation)
 }
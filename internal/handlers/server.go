@@ -0,0 +1,114 @@
+// server.go
+//
+// Server implements api.StrictServerInterface, the operations of
+// spec/openapi.yaml, and the operational routes of the admin listener. Its
+// dependencies are injected through Deps rather than read from package
+// state, so tests can substitute fakes and several instances can run in one
+// process. An operation added to the spec fails the build here until Server
+// has a method for it. Route it with api.RegisterHandlers.
+
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/paulcapestany/toy-service/internal/api"
+	"github.com/paulcapestany/toy-service/internal/apidocs"
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/health"
+	"github.com/paulcapestany/toy-service/internal/loglevel"
+	"github.com/paulcapestany/toy-service/internal/problem"
+	"github.com/paulcapestany/toy-service/internal/secrets"
+)
+
+// BuildInfo identifies the running build in /echo, /info and /version.
+type BuildInfo struct {
+	Name    string
+	Version string
+	Commit  string
+}
+
+// Deps are the dependencies of a Server. Config is required; every other
+// field has a default, so tests only set what they exercise.
+//
+// Handlers record no metrics themselves: request counts come from the
+// metrics middleware and reload outcomes from the Reloader's observer
+// (secrets.Observer), so fakes for those go there rather than in Deps.
+type Deps struct {
+	// Config provides the live configuration snapshot.
+	Config config.Provider
+	// Probes runs the checks behind /livez, /readyz and /startupz; an
+	// empty registry by default.
+	Probes *health.Registry
+	// Docs renders /openapi.yaml and /openapi.json; needed only when they
+	// are routed.
+	Docs *apidocs.Docs
+	// Reloader applies POST /-/reload; needed only when it is routed.
+	Reloader *secrets.Reloader
+	// MetricsHandler serves GET /metrics (e.g. metrics.Metrics.Handler());
+	// a problem+json 404 by default.
+	MetricsHandler http.Handler
+	// Level reports the active log level in /info and /-/log-level;
+	// loglevel.Current by default.
+	Level func() zerolog.Level
+	// SetLevel applies PUT /-/log-level and returns the previous level;
+	// loglevel.Set by default. The defaults change the process-wide level
+	// shared by every Server; tests inject their own pair to leave it alone.
+	SetLevel func(zerolog.Level) zerolog.Level
+	// Logger is used by requests that carry no logger of their own, i.e.
+	// without the requestid middleware; the global logger by default.
+	Logger *zerolog.Logger
+	// Clock returns the current time; time.Now by default.
+	Clock func() time.Time
+	// Build identifies the running build; by default the name, version and
+	// commit of Config's initial snapshot.
+	Build BuildInfo
+}
+
+// Server serves the public API and the operational routes.
+type Server struct {
+	deps    Deps
+	started time.Time
+}
+
+var _ api.StrictServerInterface = (*Server)(nil)
+
+// NewServer returns a Server on deps, filling in the defaults for the
+// fields left unset.
+func NewServer(deps Deps) *Server {
+	if deps.Probes == nil {
+		deps.Probes = health.NewRegistry()
+	}
+	if deps.MetricsHandler == nil {
+		deps.MetricsHandler = http.HandlerFunc(problem.NotFound)
+	}
+	if deps.Level == nil {
+		deps.Level = loglevel.Current
+	}
+	if deps.SetLevel == nil {
+		deps.SetLevel = loglevel.Set
+	}
+	if deps.Logger == nil {
+		deps.Logger = &log.Logger
+	}
+	if deps.Clock == nil {
+		deps.Clock = time.Now
+	}
+	if deps.Build == (BuildInfo{}) {
+		snap := deps.Config.Load()
+		deps.Build = BuildInfo{Name: snap.Name, Version: snap.Version, Commit: snap.GitCommit}
+	}
+	return &Server{deps: deps, started: deps.Clock()}
+}
+
+// Metrics handles GET /metrics requests.
+func (s *Server) Metrics(w http.ResponseWriter, r *http.Request) {
+	s.deps.MetricsHandler.ServeHTTP(w, r)
+}
+
+// noStore is the Cache-Control of responses that reflect live state.
+const noStore = "no-store"
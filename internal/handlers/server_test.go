@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/api"
+	"github.com/paulcapestany/toy-service/internal/apidocs"
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/problem"
+	"github.com/paulcapestany/toy-service/internal/secrets"
+	"github.com/paulcapestany/toy-service/spec"
+)
+
+// testConfig returns a store seeded with representative defaults, optionally
+// adjusted by the provided mutators before the first snapshot is published.
+func testConfig(mutators ...func(*config.Snapshot)) *config.Store {
+	snap := config.Snapshot{
+		Name:      "toy-service",
+		Env:       "test",
+		Version:   "v0.0.0-test",
+		GitCommit: "abc1234",
+		SecretDir: config.DefaultSecretDir,
+	}
+	for _, mutate := range mutators {
+		mutate(&snap)
+	}
+	return config.NewStore(snap)
+}
+
+// testServer returns a Server on cfg with no health checks registered.
+func testServer(t *testing.T, cfg config.Provider) *Server {
+	t.Helper()
+	docs, err := apidocs.New(spec.OpenAPI, cfg, apidocs.Options{})
+	require.NoError(t, err)
+	return NewServer(Deps{Config: cfg, Docs: docs})
+}
+
+// fakeClock returns a settable time.
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestServer_IndependentInstances(t *testing.T) {
+	t.Log("Test that instances in one process report their own build and uptime")
+
+	start := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	clocks := []*fakeClock{{now: start}, {now: start}}
+	routers := make([]*chi.Mux, 2)
+	for i, build := range []BuildInfo{
+		{Name: "toy-service", Version: "v1.0.0", Commit: "aaa"},
+		{Name: "embedded", Version: "v2.0.0", Commit: "bbb"},
+	} {
+		routers[i] = chi.NewRouter()
+		api.RegisterHandlers(routers[i], NewServer(Deps{Config: testConfig(), Clock: clocks[i].Now, Build: build}))
+	}
+	clocks[0].now = start.Add(90 * time.Second)
+	clocks[1].now = start.Add(time.Hour)
+
+	get := func(r http.Handler, path string, v any) {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code, path)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+	}
+
+	var info api.InfoResponse
+	get(routers[0], "/info", &info)
+	require.Equal(t, "v1.0.0", info.Version)
+	require.Equal(t, "aaa", info.Commit)
+	require.Equal(t, 90, info.UptimeSeconds)
+	get(routers[1], "/info", &info)
+	require.Equal(t, "embedded", info.Name)
+	require.Equal(t, 3600, info.UptimeSeconds)
+
+	var version api.VersionResponse
+	get(routers[1], "/version", &version)
+	require.Equal(t, api.VersionResponse{Name: "embedded", Version: "v2.0.0", Commit: "bbb"}, version)
+}
+
+func TestNewServer_Defaults(t *testing.T) {
+	t.Log("Test that build info defaults to the initial snapshot and /metrics to a 404")
+
+	s := NewServer(Deps{Config: testConfig()})
+	require.Equal(t, BuildInfo{Name: "toy-service", Version: "v0.0.0-test", Commit: "abc1234"}, s.deps.Build)
+
+	rec := httptest.NewRecorder()
+	s.Metrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+
+	rec = httptest.NewRecorder()
+	NewServer(Deps{Config: testConfig(), MetricsHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "# fake\n")
+	})}).Metrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, "# fake\n", rec.Body.String())
+}
+
+func TestServer_LogsToInjectedLogger(t *testing.T) {
+	t.Log("Test that requests without a logger of their own log to Deps.Logger")
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	store := testConfig(func(s *config.Snapshot) {
+		s.SecretDir = filepath.Join(t.TempDir(), "missing")
+	})
+	s := NewServer(Deps{Config: store, Reloader: secrets.NewReloader(store, nil), Logger: &logger})
+
+	rec := httptest.NewRecorder()
+	s.Reload(rec, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Contains(t, buf.String(), "failed reading secret files")
+}
+
+func TestServer_InjectedLogLevel(t *testing.T) {
+	t.Log("Test that /info and /-/log-level use the injected level instead of the global one")
+
+	global := zerolog.GlobalLevel()
+	level := zerolog.WarnLevel
+	s := NewServer(Deps{
+		Config: testConfig(),
+		Level:  func() zerolog.Level { return level },
+		SetLevel: func(l zerolog.Level) zerolog.Level {
+			prev := level
+			level = l
+			return prev
+		},
+	})
+	r := chi.NewRouter()
+	api.RegisterHandlers(r, s)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/info", nil))
+	var info api.InfoResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+	require.Equal(t, "warn", info.LogVerbosity)
+
+	rec = httptest.NewRecorder()
+	s.LogLevel(rec, httptest.NewRequest(http.MethodPut, "/-/log-level", strings.NewReader(`{"level":"debug"}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"level":"debug","previous":"warn"}`, rec.Body.String())
+	require.Equal(t, zerolog.DebugLevel, level)
+	require.Equal(t, global, zerolog.GlobalLevel())
+}
@@ -5,32 +5,23 @@
 package handlers
 
 import (
-	"encoding/json"
-	"net/http"
+	"context"
 
-	"github.com/rs/zerolog/log"
+	"github.com/paulcapestany/toy-service/internal/api"
 )
 
-// VersionHandler handles GET /version requests.
+// GetVersion handles GET /version requests.
 // It returns the service name, semantic version, and git commit hash for quick checks.
-func VersionHandler(w http.ResponseWriter, r *http.Request) {
-	log.Debug().Msg("Handling /version request")
-
-	cfg := LoadEnvConfig()
-
-	resp := map[string]string{
-		"name":    cfg.Name,
-		"version": cfg.Version,
-		"commit":  cfg.GitCommit,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Cache-Control", "no-store")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Error().Err(err).Msg("Failed to write /version response")
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+func (s *Server) GetVersion(ctx context.Context, request api.GetVersionRequestObject) (api.GetVersionResponseObject, error) {
+	logger := s.logger(ctx)
+	logger.Debug().Msg("Handling /version request")
+
+	resp := api.VersionResponse{
+		Name:    s.deps.Build.Name,
+		Version: s.deps.Build.Version,
+		Commit:  s.deps.Build.Commit,
 	}
 
-	log.Debug().Msg("/version response successfully returned")
+	logger.Debug().Msg("/version response successfully returned")
+	return api.GetVersion200JSONResponse{Body: resp, Headers: api.GetVersion200ResponseHeaders{CacheControl: noStore}}, nil
 }
@@ -8,13 +8,15 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/api"
 )
 
 func TestVersionHandler(t *testing.T) {
 	t.Log("Test that /version returns service build metadata")
 
 	r := chi.NewRouter()
-	r.Get("/version", VersionHandler)
+	api.RegisterHandlers(r, testServer(t, testConfig()))
 
 	req, err := http.NewRequest("GET", "/version", nil)
 	require.NoError(t, err)
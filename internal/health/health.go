@@ -0,0 +1,235 @@
+// health.go
+//
+// Provides a registry of named health checks backing the /livez, /readyz and
+// /startupz probes. Each check declares which probes it contributes to, a
+// timeout and an optional cache TTL; checks for a probe run concurrently and
+// the probe fails if any of them fails.
+
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paulcapestany/toy-service/internal/logctx"
+)
+
+// Probe is a bit set of the probes a check contributes to.
+type Probe uint8
+
+// Probes served by the registry.
+const (
+	Liveness Probe = 1 << iota
+	Readiness
+	Startup
+)
+
+// String returns the probe's name as used in logs.
+func (p Probe) String() string {
+	switch p {
+	case Liveness:
+		return "liveness"
+	case Readiness:
+		return "readiness"
+	case Startup:
+		return "startup"
+	default:
+		return fmt.Sprintf("probe(%d)", uint8(p))
+	}
+}
+
+// DefaultTimeout bounds a check that does not set its own timeout.
+const DefaultTimeout = 2 * time.Second
+
+// Status is the outcome of a check or probe.
+type Status string
+
+// Check and probe statuses.
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+// Check is a named health check.
+type Check struct {
+	// Name identifies the check in verbose output and logs; it must be unique.
+	Name string
+	// Probes lists the probes the check contributes to (e.g. Readiness|Startup).
+	Probes Probe
+	// Timeout bounds a single run; DefaultTimeout if zero.
+	Timeout time.Duration
+	// CacheTTL reuses the last result for this long; zero runs the check on
+	// every probe.
+	CacheTTL time.Duration
+	// Run returns nil when healthy. It should honour ctx cancellation, but a
+	// check that does not is still reported as failed once Timeout elapses.
+	Run func(ctx context.Context) error
+}
+
+// Result is the outcome of one check within a probe.
+type Result struct {
+	Name     string  `json:"name"`
+	Status   Status  `json:"status"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"durationMs"`
+	Cached   bool    `json:"cached,omitempty"`
+}
+
+// Report is the outcome of a probe.
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks,omitempty"`
+}
+
+// Registry holds the registered checks. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	checks  []*entry
+	started atomic.Bool
+}
+
+// entry is a registered check plus its cached result and last status.
+type entry struct {
+	check Check
+
+	mu     sync.Mutex
+	last   Result
+	ranAt  time.Time
+	failed bool
+}
+
+// NewRegistry returns an empty registry. Probes with no checks report ok.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds c to the registry.
+func (r *Registry) Register(c Check) error {
+	if c.Name == "" || c.Run == nil {
+		return errors.New("health check needs a name and a Run function")
+	}
+	if c.Probes&(Liveness|Readiness|Startup) == 0 {
+		return fmt.Errorf("health check %q is not attached to any probe", c.Name)
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.checks {
+		if e.check.Name == c.Name {
+			return fmt.Errorf("health check %q already registered", c.Name)
+		}
+	}
+	r.checks = append(r.checks, &entry{check: c})
+	return nil
+}
+
+// Run executes every check attached to probe and aggregates the results,
+// sorted by name. Once a startup probe has passed it keeps passing without
+// re-running its checks, matching Kubernetes startup probe semantics.
+func (r *Registry) Run(ctx context.Context, probe Probe) Report {
+	if probe == Startup && r.started.Load() {
+		return Report{Status: StatusOK}
+	}
+
+	r.mu.RLock()
+	var entries []*entry
+	for _, e := range r.checks {
+		if e.check.Probes&probe != 0 {
+			entries = append(entries, e)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(entries))
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			results[i] = e.run(ctx)
+		}(i, e)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	report := Report{Status: StatusOK, Checks: results}
+	for _, res := range results {
+		if res.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	if probe == Startup && report.Status == StatusOK {
+		r.started.Store(true)
+	}
+	return report
+}
+
+// run executes the check (or returns its cached result) and logs transitions
+// between healthy and failing to the zerolog logger in ctx, if any.
+func (e *entry) run(ctx context.Context) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.check.CacheTTL > 0 && !e.ranAt.IsZero() && time.Since(e.ranAt) < e.check.CacheTTL {
+		res := e.last
+		res.Cached = true
+		return res
+	}
+
+	start := time.Now()
+	err := runWithTimeout(ctx, e.check.Run, e.check.Timeout)
+	res := Result{
+		Name:     e.check.Name,
+		Status:   StatusOK,
+		Duration: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	if failed := err != nil; failed != e.failed {
+		logger := logctx.From(ctx)
+		if failed {
+			logger.Warn().Err(err).Str("check", e.check.Name).Msg("Health check failing")
+		} else {
+			logger.Info().Str("check", e.check.Name).Msg("Health check recovered")
+		}
+		e.failed = failed
+	}
+
+	e.last, e.ranAt = res, time.Now()
+	return res
+}
+
+// runWithTimeout runs fn and gives up after timeout even if fn ignores ctx.
+func runWithTimeout(ctx context.Context, fn func(context.Context) error, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("check panicked: %v", p)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+}
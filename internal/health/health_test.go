@@ -0,0 +1,106 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ok(context.Context) error { return nil }
+
+func TestRegister_Validates(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(Check{Name: "a", Probes: Liveness, Run: ok}))
+	require.Error(t, r.Register(Check{Name: "a", Probes: Readiness, Run: ok}), "duplicate name")
+	require.Error(t, r.Register(Check{Name: "", Probes: Readiness, Run: ok}))
+	require.Error(t, r.Register(Check{Name: "b", Probes: Readiness}))
+	require.Error(t, r.Register(Check{Name: "c", Run: ok}), "no probe")
+}
+
+func TestRun_SelectsChecksByProbe(t *testing.T) {
+	t.Log("Test that a probe only runs its own checks and fails if any of them fails")
+
+	r := NewRegistry()
+	require.NoError(t, r.Register(Check{Name: "config", Probes: Liveness | Readiness, Run: ok}))
+	require.NoError(t, r.Register(Check{Name: "db", Probes: Readiness, Run: func(context.Context) error {
+		return errors.New("connection refused")
+	}}))
+
+	live := r.Run(context.Background(), Liveness)
+	require.Equal(t, StatusOK, live.Status)
+	require.Len(t, live.Checks, 1)
+
+	ready := r.Run(context.Background(), Readiness)
+	require.Equal(t, StatusFail, ready.Status)
+	require.Equal(t, []string{"config", "db"}, []string{ready.Checks[0].Name, ready.Checks[1].Name})
+	require.Equal(t, StatusFail, ready.Checks[1].Status)
+	require.Equal(t, "connection refused", ready.Checks[1].Error)
+
+	require.Equal(t, StatusOK, r.Run(context.Background(), Startup).Status, "no checks means ok")
+}
+
+func TestRun_Timeout(t *testing.T) {
+	t.Log("Test that a check ignoring its context is failed once its timeout elapses")
+
+	r := NewRegistry()
+	block := make(chan struct{})
+	defer close(block)
+	require.NoError(t, r.Register(Check{Name: "slow", Probes: Readiness, Timeout: 20 * time.Millisecond, Run: func(context.Context) error {
+		<-block
+		return nil
+	}}))
+
+	start := time.Now()
+	report := r.Run(context.Background(), Readiness)
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, StatusFail, report.Status)
+	require.Contains(t, report.Checks[0].Error, "timed out")
+}
+
+func TestRun_Panic(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(Check{Name: "boom", Probes: Liveness, Run: func(context.Context) error { panic("boom") }}))
+	report := r.Run(context.Background(), Liveness)
+	require.Equal(t, StatusFail, report.Status)
+	require.Contains(t, report.Checks[0].Error, "panicked")
+}
+
+func TestRun_Cache(t *testing.T) {
+	t.Log("Test that results are reused within CacheTTL")
+
+	r := NewRegistry()
+	var calls atomic.Int32
+	require.NoError(t, r.Register(Check{Name: "cached", Probes: Readiness, CacheTTL: time.Hour, Run: func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}}))
+
+	first := r.Run(context.Background(), Readiness)
+	second := r.Run(context.Background(), Readiness)
+	require.Equal(t, int32(1), calls.Load())
+	require.False(t, first.Checks[0].Cached)
+	require.True(t, second.Checks[0].Cached)
+}
+
+func TestRun_StartupLatches(t *testing.T) {
+	t.Log("Test that the startup probe keeps passing once it has passed")
+
+	r := NewRegistry()
+	var healthy atomic.Bool
+	require.NoError(t, r.Register(Check{Name: "warmup", Probes: Startup, Run: func(context.Context) error {
+		if !healthy.Load() {
+			return errors.New("warming up")
+		}
+		return nil
+	}}))
+
+	require.Equal(t, StatusFail, r.Run(context.Background(), Startup).Status)
+	healthy.Store(true)
+	require.Equal(t, StatusOK, r.Run(context.Background(), Startup).Status)
+	healthy.Store(false)
+	require.Equal(t, StatusOK, r.Run(context.Background(), Startup).Status)
+}
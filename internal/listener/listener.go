@@ -0,0 +1,191 @@
+// listener.go
+//
+// Opens the public listener from configuration: a systemd-style activated
+// socket (LISTEN_FDS, when enabled), a Unix domain socket, or TCP on PORT, in
+// that order of precedence. It also enables HTTP/2 over cleartext (h2c) for service-mesh
+// sidecars that speak HTTP/2 without TLS.
+
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/net/netutil"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+// DefaultSocketMode is the permission applied to a Unix socket: owner and
+// group (e.g. a same-pod sidecar sharing an fsGroup) may connect.
+const DefaultSocketMode os.FileMode = 0o660
+
+// firstActivatedFD is the first file descriptor passed by systemd.
+const firstActivatedFD = 3
+
+// Source identifies where a listener came from.
+type Source string
+
+// Listener sources.
+const (
+	SourceTCP     Source = "tcp"
+	SourceUnix    Source = "unix"
+	SourceSystemd Source = "systemd"
+)
+
+// Options configures the public listener.
+type Options struct {
+	// SocketActivation uses a socket passed via LISTEN_FDS, if any. Only the
+	// process the socket was passed to should set it, since the LISTEN_*
+	// variables are cleared once read.
+	SocketActivation bool
+	// UnixSocket is a socket path to listen on instead of TCP.
+	UnixSocket string
+	// SocketMode is applied to UnixSocket after it is created.
+	SocketMode os.FileMode
+	// H2C serves HTTP/2 over cleartext alongside HTTP/1.1.
+	H2C bool
+	// MaxConnections caps concurrently open connections; further clients
+	// wait in the accept queue. Zero means unlimited.
+	MaxConnections int
+}
+
+// OptionsFromConfig builds Options from the server and limits configuration.
+func OptionsFromConfig(cfg config.Server, limits config.Limits) (Options, error) {
+	opts := Options{
+		UnixSocket:     cfg.UnixSocket,
+		SocketMode:     DefaultSocketMode,
+		H2C:            cfg.H2C,
+		MaxConnections: limits.MaxConnections,
+	}
+	if cfg.UnixSocketMode != "" {
+		mode, err := strconv.ParseUint(cfg.UnixSocketMode, 8, 32)
+		if err != nil || mode > 0o777 {
+			return Options{}, fmt.Errorf("invalid unix socket mode %q (want octal, e.g. 0660)", cfg.UnixSocketMode)
+		}
+		opts.SocketMode = os.FileMode(mode)
+	}
+	return opts, nil
+}
+
+// Open returns the public listener: the first socket passed via LISTEN_FDS
+// if opts.SocketActivation is set and there is one, else opts.UnixSocket if set, else TCP on addr. It is limited to
+// opts.MaxConnections concurrent connections when that is set.
+func Open(addr string, opts Options) (net.Listener, Source, error) {
+	ln, source, err := open(addr, opts)
+	if err != nil || opts.MaxConnections <= 0 {
+		return ln, source, err
+	}
+	return netutil.LimitListener(ln, opts.MaxConnections), source, nil
+}
+
+func open(addr string, opts Options) (net.Listener, Source, error) {
+	if opts.SocketActivation {
+		ln, err := activated()
+		if err != nil {
+			return nil, "", err
+		}
+		if ln != nil {
+			return ln, SourceSystemd, nil
+		}
+	}
+
+	if opts.UnixSocket != "" {
+		ln, err := listenUnix(opts.UnixSocket, opts.SocketMode)
+		return ln, SourceUnix, err
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	return ln, SourceTCP, err
+}
+
+// activated returns the first socket passed by systemd (or any supervisor
+// following the sd_listen_fds protocol), or nil when none was passed. The
+// LISTEN_* variables are cleared so child processes do not inherit them.
+func activated() (net.Listener, error) {
+	rawFDs := strings.TrimSpace(os.Getenv("LISTEN_FDS"))
+	if rawFDs == "" {
+		return nil, nil
+	}
+	if pid := strings.TrimSpace(os.Getenv("LISTEN_PID")); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		// Meant for another process (e.g. inherited through a wrapper).
+		return nil, nil
+	}
+	defer func() {
+		for _, key := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+			_ = os.Unsetenv(key)
+		}
+	}()
+
+	n, err := strconv.Atoi(rawFDs)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", rawFDs)
+	}
+	if n > 1 {
+		// Extra sockets are not used; close them so they are not leaked.
+		for fd := firstActivatedFD + 1; fd < firstActivatedFD+n; fd++ {
+			_ = os.NewFile(uintptr(fd), "").Close()
+		}
+	}
+
+	return listenFD(firstActivatedFD)
+}
+
+// listenFD returns a listener for the socket open on fd, taking ownership of
+// fd. os.NewFile accepts any fd, so a closed one only shows up as EBADF when
+// net.FileListener duplicates it.
+func listenFD(fd uintptr) (net.Listener, error) {
+	f := os.NewFile(fd, "LISTEN_FD_"+strconv.FormatUint(uint64(fd), 10))
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if errors.Is(err, syscall.EBADF) {
+		return nil, fmt.Errorf("LISTEN_FDS set but file descriptor %d is not open", fd)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("activated socket: %w", err)
+	}
+	return ln, nil
+}
+
+// listenUnix listens on path, replacing a stale socket left by a previous
+// run, and applies mode. The socket file is removed when the listener closes.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// EnableH2C makes srv accept HTTP/2 over cleartext (prior knowledge or
+// Upgrade: h2c) in addition to HTTP/1.1. HTTP/2 connections take part in
+// srv.Shutdown, which sends them GOAWAY.
+func EnableH2C(srv *http.Server) error {
+	h2s := &http2.Server{}
+	if err := http2.ConfigureServer(srv, h2s); err != nil {
+		return err
+	}
+	srv.Handler = h2c.NewHandler(srv.Handler, h2s)
+	return nil
+}
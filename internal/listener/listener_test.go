@@ -0,0 +1,195 @@
+package listener
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+func TestOptionsFromConfig(t *testing.T) {
+	opts, err := OptionsFromConfig(config.Server{}, config.Limits{})
+	require.NoError(t, err)
+	require.Equal(t, Options{SocketMode: DefaultSocketMode}, opts)
+
+	opts, err = OptionsFromConfig(config.Server{UnixSocket: "/run/toy/http.sock", UnixSocketMode: "0600", H2C: true}, config.Limits{MaxConnections: 10})
+	require.NoError(t, err)
+	require.Equal(t, Options{UnixSocket: "/run/toy/http.sock", SocketMode: 0o600, H2C: true, MaxConnections: 10}, opts)
+
+	_, err = OptionsFromConfig(config.Server{UnixSocketMode: "rw-rw----"}, config.Limits{})
+	require.Error(t, err)
+}
+
+func TestOpen_TCP(t *testing.T) {
+	t.Setenv("LISTEN_FDS", "")
+	ln, src, err := Open("127.0.0.1:0", Options{})
+	require.NoError(t, err)
+	defer ln.Close()
+	require.Equal(t, SourceTCP, src)
+}
+
+func TestOpen_MaxConnections(t *testing.T) {
+	t.Log("Test that connections beyond MaxConnections wait until one closes")
+	t.Setenv("LISTEN_FDS", "")
+
+	ln, _, err := Open("127.0.0.1:0", Options{MaxConnections: 1})
+	require.NoError(t, err)
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+	}
+
+	first := <-accepted
+	select {
+	case <-accepted:
+		t.Fatal("second connection accepted while the first is open")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, first.Close())
+	select {
+	case conn := <-accepted:
+		conn.Close()
+	case <-time.After(time.Second):
+		t.Fatal("second connection not accepted after the first closed")
+	}
+}
+
+func TestOpen_UnixSocket(t *testing.T) {
+	t.Setenv("LISTEN_FDS", "")
+	path := filepath.Join(t.TempDir(), "http.sock")
+
+	t.Log("Test that a stale socket is replaced and the mode applied")
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, src, err := Open(":0", Options{UnixSocket: path, SocketMode: 0o600})
+	require.NoError(t, err)
+	require.Equal(t, SourceUnix, src)
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello")
+	})}
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://unix/")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, "hello", string(body))
+
+	t.Log("Test that a regular file at the socket path is never removed")
+	file := filepath.Join(t.TempDir(), "not-a-socket")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+	_, _, err = Open(":0", Options{UnixSocket: file})
+	require.Error(t, err)
+	require.FileExists(t, file)
+}
+
+func TestOpen_ActivationForAnotherProcess(t *testing.T) {
+	t.Log("Test that LISTEN_FDS addressed to another PID is ignored")
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	ln, src, err := Open("127.0.0.1:0", Options{SocketActivation: true})
+	require.NoError(t, err)
+	defer ln.Close()
+	require.Equal(t, SourceTCP, src)
+}
+
+func TestOpen_ActivationDisabled(t *testing.T) {
+	t.Log("Test that LISTEN_FDS is ignored and left set unless socket activation is enabled")
+	t.Setenv("LISTEN_FDS", "zero")
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	ln, src, err := Open("127.0.0.1:0", Options{})
+	require.NoError(t, err)
+	defer ln.Close()
+	require.Equal(t, SourceTCP, src)
+	require.Equal(t, "zero", os.Getenv("LISTEN_FDS"))
+}
+
+func TestOpen_InvalidActivation(t *testing.T) {
+	t.Setenv("LISTEN_FDS", "zero")
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	_, _, err := Open("127.0.0.1:0", Options{SocketActivation: true})
+	require.Error(t, err)
+}
+
+func TestListenFD_Closed(t *testing.T) {
+	t.Log("Test that a closed file descriptor is reported as not open")
+	f, err := os.CreateTemp(t.TempDir(), "fd")
+	require.NoError(t, err)
+	fd := f.Fd()
+	require.NoError(t, f.Close())
+
+	_, err = listenFD(fd)
+	require.EqualError(t, err, "LISTEN_FDS set but file descriptor "+strconv.FormatUint(uint64(fd), 10)+" is not open")
+}
+
+func TestEnableH2C(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	})}
+	require.NoError(t, EnableH2C(srv))
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Close()
+
+	t.Log("Test that prior-knowledge HTTP/2 is accepted over cleartext")
+	h2 := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+	resp, err := h2.Get("http://" + ln.Addr().String())
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, "HTTP/2.0", string(body))
+
+	t.Log("Test that HTTP/1.1 keeps working")
+	resp, err = http.Get("http://" + ln.Addr().String())
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, "HTTP/1.1", string(body))
+}
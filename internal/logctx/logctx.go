@@ -0,0 +1,30 @@
+// logctx.go
+//
+// Resolves the logger to use for a context. The server stores its logger in
+// every request and background context (see pkg/server), and the requestid
+// and tracing middlewares enrich it, so packages log through the context
+// rather than the global logger. The fallback only applies where no logger
+// was stored, e.g. in unit tests.
+
+package logctx
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// From returns the logger stored in ctx, or the global logger when there is
+// none.
+func From(ctx context.Context) *zerolog.Logger {
+	return Or(ctx, &log.Logger)
+}
+
+// Or returns the logger stored in ctx, or fallback when there is none.
+func Or(ctx context.Context, fallback *zerolog.Logger) *zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return l
+	}
+	return fallback
+}
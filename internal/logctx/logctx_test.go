@@ -0,0 +1,25 @@
+package logctx
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrom(t *testing.T) {
+	t.Log("Test that the logger in the context wins over the fallback")
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	ctx := logger.WithContext(context.Background())
+	From(ctx).Info().Msg("from context")
+	require.Contains(t, buf.String(), "from context")
+
+	t.Log("Test that contexts without a logger fall back")
+	require.Same(t, &log.Logger, From(context.Background()))
+	require.Same(t, &logger, Or(context.Background(), &logger))
+}
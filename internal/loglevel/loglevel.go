@@ -0,0 +1,115 @@
+// loglevel.go
+//
+// Manages the process-wide zerolog level. The level is applied at startup
+// from LOG_VERBOSITY and can be raised or lowered at runtime (via the
+// /-/log-level admin endpoint or SIGUSR1/SIGUSR2) without a restart.
+
+package loglevel
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// Default is applied when LOG_VERBOSITY is empty or invalid.
+const Default = zerolog.InfoLevel
+
+// ordered lists the supported levels from most to least verbose.
+var ordered = []zerolog.Level{
+	zerolog.TraceLevel,
+	zerolog.DebugLevel,
+	zerolog.InfoLevel,
+	zerolog.WarnLevel,
+	zerolog.ErrorLevel,
+}
+
+// mu serialises read-modify-write updates (Increase/Decrease) against Set.
+var mu sync.Mutex
+
+// Parse converts a verbosity name (trace, debug, info, warn/warning, error)
+// into a zerolog level.
+func Parse(s string) (zerolog.Level, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "warning" {
+		name = "warn"
+	}
+	for _, l := range ordered {
+		if l.String() == name {
+			return l, nil
+		}
+	}
+	return zerolog.NoLevel, fmt.Errorf("unsupported log level %q (want one of trace, debug, info, warn, error)", s)
+}
+
+// Apply parses s and sets it as the global level. An invalid value falls back
+// to Default and is reported through the returned error.
+func Apply(s string) (zerolog.Level, error) {
+	l, err := Parse(s)
+	if err != nil {
+		Set(Default)
+		return Default, err
+	}
+	Set(l)
+	return l, nil
+}
+
+// Current returns the active global level.
+func Current() zerolog.Level {
+	return zerolog.GlobalLevel()
+}
+
+// Set changes the global level and returns the previous one.
+func Set(l zerolog.Level) zerolog.Level {
+	mu.Lock()
+	defer mu.Unlock()
+	prev := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(l)
+	return prev
+}
+
+// Increase makes logging one step more verbose (e.g. info -> debug), stopping
+// at trace. It returns the new level.
+func Increase() zerolog.Level {
+	return step(-1)
+}
+
+// Decrease makes logging one step less verbose (e.g. info -> warn), stopping
+// at error. It returns the new level.
+func Decrease() zerolog.Level {
+	return step(1)
+}
+
+func step(delta int) zerolog.Level {
+	mu.Lock()
+	defer mu.Unlock()
+
+	cur := zerolog.GlobalLevel()
+	idx := -1
+	for i, l := range ordered {
+		if l == cur {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		// Level was set outside the supported range; snap to the nearest end.
+		idx = 0
+		if cur > ordered[len(ordered)-1] {
+			idx = len(ordered) - 1
+		}
+	} else {
+		idx += delta
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(ordered) {
+		idx = len(ordered) - 1
+	}
+
+	zerolog.SetGlobalLevel(ordered[idx])
+	return ordered[idx]
+}
@@ -0,0 +1,65 @@
+package loglevel
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// restoreLevel resets the global level after the test mutates it.
+func restoreLevel(t *testing.T) {
+	t.Helper()
+	prev := zerolog.GlobalLevel()
+	t.Cleanup(func() { zerolog.SetGlobalLevel(prev) })
+}
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]zerolog.Level{
+		"debug":   zerolog.DebugLevel,
+		" INFO ":  zerolog.InfoLevel,
+		"warning": zerolog.WarnLevel,
+		"warn":    zerolog.WarnLevel,
+		"error":   zerolog.ErrorLevel,
+		"trace":   zerolog.TraceLevel,
+	} {
+		got, err := Parse(in)
+		require.NoError(t, err, in)
+		require.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "verbose", "fatal", "disabled"} {
+		_, err := Parse(in)
+		require.Error(t, err, in)
+	}
+}
+
+func TestApply_InvalidFallsBackToDefault(t *testing.T) {
+	restoreLevel(t)
+
+	l, err := Apply("debug")
+	require.NoError(t, err)
+	require.Equal(t, zerolog.DebugLevel, l)
+	require.Equal(t, zerolog.DebugLevel, Current())
+
+	l, err = Apply("chatty")
+	require.Error(t, err)
+	require.Equal(t, Default, l)
+	require.Equal(t, Default, Current())
+}
+
+func TestIncreaseDecrease(t *testing.T) {
+	restoreLevel(t)
+
+	Set(zerolog.InfoLevel)
+	require.Equal(t, zerolog.DebugLevel, Increase())
+	require.Equal(t, zerolog.TraceLevel, Increase())
+	require.Equal(t, zerolog.TraceLevel, Increase(), "stops at trace")
+
+	Set(zerolog.WarnLevel)
+	require.Equal(t, zerolog.ErrorLevel, Decrease())
+	require.Equal(t, zerolog.ErrorLevel, Decrease(), "stops at error")
+
+	Set(zerolog.Disabled)
+	require.Equal(t, zerolog.ErrorLevel, Increase(), "snaps back into range")
+}
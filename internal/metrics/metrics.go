@@ -0,0 +1,196 @@
+// metrics.go
+//
+// Provides Prometheus instrumentation for the HTTP router. The middleware records
+// RED (rate, errors, duration) metrics labelled by chi route pattern rather than
+// raw path so cardinality stays bounded, and Handler serves the registry in the
+// Prometheus text exposition format.
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes every metric exported by toy-service.
+const Namespace = "toy_service"
+
+// unmatchedRoute labels requests that did not match any registered route
+// (e.g. 404s for arbitrary paths) so they cannot inflate label cardinality.
+const unmatchedRoute = "unmatched"
+
+// otherMethod labels requests with a method outside the standard ones, which
+// any client can make up, for the same reason.
+const otherMethod = "other"
+
+// Metrics owns the Prometheus registry and the HTTP collectors registered in it.
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+
+	secretReloads      *prometheus.CounterVec
+	secretReloadLastOK prometheus.Gauge
+
+	contractViolations *prometheus.CounterVec
+	deprecatedRequests *prometheus.CounterVec
+}
+
+// New creates a Metrics instance with its own registry, pre-populated with the
+// Go runtime and process collectors.
+func New() *Metrics {
+	labels := []string{"route", "method", "status"}
+
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Total number of HTTP requests handled, by route pattern, method and status.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "request_errors_total",
+			Help:      "Total number of HTTP requests that completed with a 5xx status.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency in seconds, by route pattern, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+		secretReloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "secret",
+			Name:      "reloads_total",
+			Help:      "Total number of secret reload attempts, by trigger (webhook, watch) and result (success, failure).",
+		}, []string{"trigger", "result"}),
+		secretReloadLastOK: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "secret",
+			Name:      "last_reload_success_timestamp_seconds",
+			Help:      "Unix time of the last successful secret reload.",
+		}),
+		contractViolations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "openapi",
+			Name:      "violations_total",
+			Help:      "Total number of requests and responses that did not match the OpenAPI spec, by direction and spec path.",
+		}, []string{"direction", "route"}),
+		deprecatedRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "deprecated_requests_total",
+			Help:      "Total number of requests to deprecated routes, by API version, method and route pattern.",
+		}, []string{"version", "method", "route"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.errors,
+		m.duration,
+		m.secretReloads,
+		m.secretReloadLastOK,
+		m.contractViolations,
+		m.deprecatedRequests,
+	)
+
+	return m
+}
+
+// Registry exposes the underlying registry so other packages can register
+// their own collectors alongside the HTTP metrics.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Middleware records request count, error count and latency for every request
+// passing through the router. It must be installed with chi's Use so the route
+// pattern is resolved by the time the handler returns.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			// Handlers that never call WriteHeader or Write implicitly return 200.
+			status = http.StatusOK
+		}
+
+		route, method := routePattern(r), methodLabel(r.Method)
+		code := strconv.Itoa(status)
+		m.requests.WithLabelValues(route, method, code).Inc()
+		if status >= http.StatusInternalServerError {
+			m.errors.WithLabelValues(route, method, code).Inc()
+		}
+		m.duration.WithLabelValues(route, method, code).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveSecretReload records the outcome of a secret reload attempt. It
+// satisfies secrets.Observer.
+func (m *Metrics) ObserveSecretReload(trigger string, err error) {
+	if err != nil {
+		m.secretReloads.WithLabelValues(trigger, "failure").Inc()
+		return
+	}
+	m.secretReloads.WithLabelValues(trigger, "success").Inc()
+	m.secretReloadLastOK.SetToCurrentTime()
+}
+
+// ObserveContractViolation counts a request or response that did not match
+// the OpenAPI spec. It satisfies contract.Observer.
+func (m *Metrics) ObserveContractViolation(direction, route string) {
+	m.contractViolations.WithLabelValues(direction, route).Inc()
+}
+
+// ObserveDeprecatedRequest counts a request to a deprecated route. It
+// satisfies apiversion.Observer.
+func (m *Metrics) ObserveDeprecatedRequest(version, method, route string) {
+	m.deprecatedRequests.WithLabelValues(version, methodLabel(method), route).Inc()
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// routePattern returns the chi route pattern matched for r, or unmatchedRoute
+// when the request did not resolve to a registered route.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return unmatchedRoute
+	}
+	if pattern := rctx.RoutePattern(); pattern != "" {
+		return pattern
+	}
+	return unmatchedRoute
+}
+
+// methodLabel returns method if it is one of the standard HTTP methods and
+// otherMethod otherwise.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return otherMethod
+}
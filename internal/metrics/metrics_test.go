@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(m *Metrics) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	r.Get("/boom", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	r.Method(http.MethodGet, "/metrics", m.Handler())
+	return r
+}
+
+func scrape(t *testing.T, r http.Handler) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+func TestMiddleware_LabelsByRoutePattern(t *testing.T) {
+	t.Log("Test that request metrics are labelled by chi route pattern, not raw path")
+
+	m := New()
+	r := newTestRouter(m)
+
+	for _, path := range []string{"/items/1", "/items/2", "/items/3"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	body := scrape(t, r)
+	require.Contains(t, body, `toy_service_http_requests_total{method="GET",route="/items/{id}",status="200"} 3`)
+	require.Contains(t, body, `toy_service_http_request_duration_seconds_count{method="GET",route="/items/{id}",status="200"} 3`)
+	require.NotContains(t, body, `route="/items/1"`)
+}
+
+func TestMiddleware_CountsErrorsAndUnmatched(t *testing.T) {
+	t.Log("Test that 5xx responses are counted as errors and unknown paths share one label")
+
+	m := New()
+	r := newTestRouter(m)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/does-not-exist", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	body := scrape(t, r)
+	require.Contains(t, body, `toy_service_http_request_errors_total{method="GET",route="/boom",status="500"} 1`)
+	require.Contains(t, body, `toy_service_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	require.NotContains(t, body, `toy_service_http_request_errors_total{method="GET",route="unmatched"`)
+}
+
+func TestMiddleware_BoundsMethodLabel(t *testing.T) {
+	t.Log("Test that non-standard methods share one label")
+
+	m := New()
+	r := newTestRouter(m)
+	for _, method := range []string{"FOO", "BAR", "get"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/items/1", nil))
+	}
+	m.ObserveDeprecatedRequest("unversioned", "PURGE", "/echo")
+
+	body := scrape(t, r)
+	require.Contains(t, body, `toy_service_http_requests_total{method="other",route="unmatched",status="405"} 3`)
+	require.Contains(t, body, `method="other",route="/echo",version="unversioned"} 1`)
+	require.NotContains(t, body, `method="FOO"`)
+	require.NotContains(t, body, `method="get"`)
+	require.NotContains(t, body, `method="PURGE"`)
+}
+
+func TestHandler_IncludesRuntimeCollectors(t *testing.T) {
+	t.Log("Test that Go runtime and process collectors are exposed")
+
+	body := scrape(t, newTestRouter(New()))
+	require.Contains(t, body, "go_goroutines")
+	require.Contains(t, body, "process_start_time_seconds")
+}
+
+func TestObserveSecretReload(t *testing.T) {
+	m := New()
+	m.ObserveSecretReload("watch", nil)
+	m.ObserveSecretReload("webhook", errors.New("boom"))
+
+	body := scrape(t, newTestRouter(m))
+	require.Contains(t, body, `toy_service_secret_reloads_total{result="success",trigger="watch"} 1`)
+	require.Contains(t, body, `toy_service_secret_reloads_total{result="failure",trigger="webhook"} 1`)
+	require.NotContains(t, body, "toy_service_secret_last_reload_success_timestamp_seconds 0\n")
+}
+
+func TestObserveContractViolation(t *testing.T) {
+	m := New()
+	m.ObserveContractViolation("request", "/echo")
+	m.ObserveContractViolation("response", "/echo")
+	m.ObserveContractViolation("response", "/echo")
+
+	body := scrape(t, newTestRouter(m))
+	require.Contains(t, body, `toy_service_openapi_violations_total{direction="request",route="/echo"} 1`)
+	require.Contains(t, body, `toy_service_openapi_violations_total{direction="response",route="/echo"} 2`)
+}
+
+func TestObserveDeprecatedRequest(t *testing.T) {
+	m := New()
+	m.ObserveDeprecatedRequest("unversioned", http.MethodPost, "/echo")
+	m.ObserveDeprecatedRequest("unversioned", http.MethodPost, "/echo")
+
+	body := scrape(t, newTestRouter(m))
+	require.Contains(t, body, `toy_service_http_deprecated_requests_total{method="POST",route="/echo",version="unversioned"} 2`)
+}
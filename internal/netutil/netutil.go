@@ -0,0 +1,69 @@
+// netutil.go
+//
+// Helpers for parsing and matching lists of IP networks used by the access
+// log and spec endpoints (trusted proxies) and the authorization layer
+// (allowed networks).
+
+package netutil
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ParseNetworks parses a comma-separated list of IP addresses and CIDR
+// ranges. Bare addresses are treated as single-host networks.
+func ParseNetworks(raw string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			ip := net.ParseIP(part)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", part)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", part, err)
+		}
+		networks = append(networks, ipnet)
+	}
+	return networks, nil
+}
+
+// Contains reports whether ip belongs to any of networks.
+func Contains(networks []*net.IPNet, ip net.IP) bool {
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// PeerIP returns the IP of a request's direct peer from its RemoteAddr
+// ("host:port" or bare host), or nil if it cannot be parsed.
+func PeerIP(remoteAddr string) net.IP {
+	host := remoteAddr
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = h
+	}
+	return net.ParseIP(host)
+}
+
+// Loopback returns the IPv4 and IPv6 loopback networks.
+func Loopback() []*net.IPNet {
+	networks, _ := ParseNetworks("127.0.0.0/8,::1")
+	return networks
+}
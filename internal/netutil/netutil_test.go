@@ -0,0 +1,36 @@
+package netutil
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks("127.0.0.1, ::1 ,10.0.0.0/8,")
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+	require.True(t, Contains(networks, net.ParseIP("10.20.30.40")))
+	require.True(t, Contains(networks, net.ParseIP("::1")))
+	require.False(t, Contains(networks, net.ParseIP("127.0.0.2")))
+
+	for _, raw := range []string{"not-an-ip", "10.0.0.0/99"} {
+		_, err := ParseNetworks(raw)
+		require.Error(t, err, raw)
+	}
+}
+
+func TestPeerIP(t *testing.T) {
+	require.Equal(t, "192.0.2.1", PeerIP("192.0.2.1:1234").String())
+	require.Equal(t, "::1", PeerIP("[::1]:80").String())
+	require.Equal(t, "192.0.2.1", PeerIP("192.0.2.1").String())
+	require.Nil(t, PeerIP("@"))
+}
+
+func TestLoopback(t *testing.T) {
+	lo := Loopback()
+	require.True(t, Contains(lo, net.ParseIP("127.0.0.1")))
+	require.True(t, Contains(lo, net.ParseIP("::1")))
+	require.False(t, Contains(lo, net.ParseIP("10.0.0.1")))
+}
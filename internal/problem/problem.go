@@ -0,0 +1,196 @@
+// problem.go
+//
+// The service's error model: RFC 7807 "problem details" written as
+// application/problem+json. Clients that ask for plain application/json
+// (and not problem+json) keep receiving the legacy {"error": "..."} body so
+// existing integrations do not break.
+
+package problem
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/paulcapestany/toy-service/internal/requestid"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// Problem types beyond the generic "about:blank".
+const (
+	// TypeValidation marks requests rejected for invalid input; Errors
+	// lists the offending fields.
+	TypeValidation = "urn:toy-service:problem:validation"
+)
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	// Type identifies the problem type; "about:blank" means the status code
+	// says it all.
+	Type string `json:"type"`
+	// Title is a short summary of the type, e.g. "Bad Request".
+	Title string `json:"title"`
+	// Status repeats the HTTP status code.
+	Status int `json:"status"`
+	// Detail explains this occurrence.
+	Detail string `json:"detail,omitempty"`
+	// Instance is the request path the problem occurred on.
+	Instance string `json:"instance,omitempty"`
+	// RequestID correlates the response with server logs.
+	RequestID string `json:"requestId,omitempty"`
+	// Errors lists field-level validation failures.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes one invalid field in a request.
+type FieldError struct {
+	// Field is a JSON pointer-like path to the field, e.g. "message".
+	Field string `json:"field"`
+	// Detail says what is wrong with it.
+	Detail string `json:"detail"`
+}
+
+// Legacy is the error body used before problem details, still served to
+// clients that accept application/json but not application/problem+json.
+type Legacy struct {
+	Error     string `json:"error"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// New returns a generic problem for status with the given detail.
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Validation returns a 400 problem listing the invalid fields.
+func Validation(detail string, errs ...FieldError) *Problem {
+	p := New(http.StatusBadRequest, detail)
+	p.Type = TypeValidation
+	p.Title = "Invalid request"
+	p.Errors = errs
+	return p
+}
+
+// Error makes a Problem usable as an error, so a handler can return one
+// to have it written as is.
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return fmt.Sprintf("%d %s", p.Status, p.Title)
+	}
+	return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+}
+
+// Write sends p, filling in the instance and request ID from r, in the shape
+// negotiated from r's Accept header.
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = requestid.FromContext(r.Context())
+	}
+
+	if wantsLegacy(r) {
+		msg := p.Detail
+		if msg == "" {
+			msg = p.Title
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(p.Status)
+		_ = json.NewEncoder(w).Encode(Legacy{Error: msg, RequestID: p.RequestID})
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// Error writes a generic problem for status with the given detail.
+func Error(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	Write(w, r, New(status, detail))
+}
+
+// NotFound answers requests for unknown routes; install it with
+// chi's Router.NotFound.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	Error(w, r, http.StatusNotFound, "no route for "+r.URL.Path)
+}
+
+// MethodNotAllowed answers requests with an unsupported method; install it
+// with chi's Router.MethodNotAllowed. The Allow header lists the methods
+// registered for the path, as RFC 9110 requires for a 405.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	if allowed := allowedMethods(r); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+	}
+	Error(w, r, http.StatusMethodNotAllowed, r.Method+" is not supported for "+r.URL.Path)
+}
+
+// methods are the methods probed for the Allow header.
+var methods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// allowedMethods returns the methods the router handling r has registered
+// for its path. chi does not record them, so each method is matched in turn.
+func allowedMethods(r *http.Request) []string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return nil
+	}
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+	var allowed []string
+	for _, method := range methods {
+		if rctx.Routes.Match(chi.NewRouteContext(), method, path) {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// wantsLegacy reports whether the client accepts application/json but not
+// application/problem+json. Clients without a preference get problem details.
+func wantsLegacy(r *http.Request) bool {
+	plain, problem := false, false
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || refused(params) {
+			continue
+		}
+		switch mediaType {
+		case "application/json":
+			plain = true
+		case ContentType:
+			problem = true
+		}
+	}
+	return plain && !problem
+}
+
+// refused reports whether a media range's quality value is zero, e.g.
+// "q=0" or "q=0.000".
+func refused(params map[string]string) bool {
+	q, ok := params["q"]
+	if !ok {
+		return false
+	}
+	v, err := strconv.ParseFloat(q, 64)
+	return err == nil && v == 0
+}
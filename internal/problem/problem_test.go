@@ -0,0 +1,107 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/requestid"
+)
+
+func TestWrite(t *testing.T) {
+	t.Log("Test that problems are written as application/problem+json with instance and request ID")
+
+	h := requestid.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, Validation("Invalid input", FieldError{Field: "message", Detail: "must not be empty"}))
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/echo", nil)
+	req.Header.Set(requestid.Header, "req-1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, ContentType, w.Header().Get("Content-Type"))
+
+	var p Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	require.Equal(t, Problem{
+		Type:      TypeValidation,
+		Title:     "Invalid request",
+		Status:    http.StatusBadRequest,
+		Detail:    "Invalid input",
+		Instance:  "/echo",
+		RequestID: "req-1",
+		Errors:    []FieldError{{Field: "message", Detail: "must not be empty"}},
+	}, p)
+}
+
+func TestWrite_Negotiation(t *testing.T) {
+	t.Log("Test that clients accepting only application/json get the legacy error shape")
+
+	for _, tc := range []struct {
+		accept string
+		legacy bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{ContentType, false},
+		{"application/json", true},
+		{"application/json, text/plain;q=0.5", true},
+		{"application/problem+json, application/json", false},
+		{"application/json, application/problem+json;q=0", true},
+		{"application/json, application/problem+json;q=0.0", true},
+		{"application/json, application/problem+json;q=0.000", true},
+		{"application/json;q=0.0, application/problem+json", false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+		req.Header.Set("Accept", tc.accept)
+		w := httptest.NewRecorder()
+		NotFound(w, req)
+
+		require.Equal(t, http.StatusNotFound, w.Code, tc.accept)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		if tc.legacy {
+			require.Equal(t, "application/json", w.Header().Get("Content-Type"), tc.accept)
+			require.Equal(t, map[string]any{"error": "no route for /missing"}, body, tc.accept)
+		} else {
+			require.Equal(t, ContentType, w.Header().Get("Content-Type"), tc.accept)
+			require.Equal(t, "Not Found", body["title"], tc.accept)
+			require.Equal(t, "about:blank", body["type"], tc.accept)
+		}
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/echo", nil)
+	w := httptest.NewRecorder()
+	MethodNotAllowed(w, req)
+
+	var p Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	require.Equal(t, http.StatusMethodNotAllowed, p.Status)
+	require.Equal(t, "DELETE is not supported for /echo", p.Detail)
+	require.Empty(t, w.Header().Get("Allow"))
+
+	t.Log("Test that the Allow header lists the methods registered for the path")
+	r := chi.NewRouter()
+	r.MethodNotAllowed(MethodNotAllowed)
+	r.Get("/items/{id}", func(http.ResponseWriter, *http.Request) {})
+	r.Put("/items/{id}", func(http.ResponseWriter, *http.Request) {})
+	r.Route("/v1", func(r chi.Router) {
+		r.Post("/echo", func(http.ResponseWriter, *http.Request) {})
+	})
+	for path, allow := range map[string]string{
+		"/items/42": "GET, PUT",
+		"/v1/echo":  "POST",
+	} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, path, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code, path)
+		require.Equal(t, allow, w.Header().Get("Allow"), path)
+	}
+}
@@ -0,0 +1,87 @@
+// requestid.go
+//
+// Provides middleware that assigns every request an ID so failures seen by
+// clients (e.g. toy-web) can be correlated with backend logs. A well-formed
+// inbound X-Request-Id is reused; anything else is replaced with a freshly
+// generated ID.
+
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/paulcapestany/toy-service/internal/logctx"
+)
+
+// Header is the HTTP header carrying the request ID in both directions.
+const Header = "X-Request-Id"
+
+// maxLength bounds accepted inbound IDs so clients cannot bloat log lines.
+const maxLength = 128
+
+type ctxKey struct{}
+
+// Middleware resolves the request ID, stores it in the request context,
+// echoes it on the response and attaches it to a request-scoped zerolog
+// logger retrievable via zerolog.Ctx. The request-scoped logger derives from
+// the logger already in the context, if any, and the global logger
+// otherwise.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !Valid(id) {
+			id = generate()
+		}
+
+		w.Header().Set(Header, id)
+
+		ctx := NewContext(r.Context(), id)
+		logger := logctx.From(r.Context()).With().Str("requestId", id).Logger()
+		ctx = logger.WithContext(ctx)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// NewContext returns a copy of ctx carrying the given request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or "" if none is present.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Valid reports whether id is an acceptable inbound request ID: 1-128
+// characters drawn from letters, digits, '-', '_', '.' and ':'.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// generate returns a random 128-bit ID encoded as 32 hex characters.
+func generate() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand failing means the platform is broken; there is no
+		// sensible fallback that keeps IDs unique.
+		panic("requestid: crypto/rand unavailable: " + err.Error())
+	}
+	return hex.EncodeToString(b[:])
+}
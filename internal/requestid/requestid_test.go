@@ -0,0 +1,89 @@
+package requestid
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, inbound string) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+
+	var seen string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if inbound != "" {
+		req.Header.Set(Header, inbound)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec, seen
+}
+
+func TestMiddleware_ReusesValidInboundID(t *testing.T) {
+	t.Log("Test that a well-formed inbound X-Request-Id is propagated unchanged")
+
+	rec, seen := serve(t, "toy-web:abc_123.4")
+	require.Equal(t, "toy-web:abc_123.4", seen)
+	require.Equal(t, "toy-web:abc_123.4", rec.Header().Get(Header))
+}
+
+func TestMiddleware_GeneratesWhenMissingOrInvalid(t *testing.T) {
+	t.Log("Test that missing, oversized or malformed IDs are replaced")
+
+	for _, inbound := range []string{"", "has space", "bad\nnewline", strings.Repeat("a", maxLength+1)} {
+		rec, seen := serve(t, inbound)
+		require.Len(t, seen, 32, "inbound %q", inbound)
+		require.NotEqual(t, inbound, seen)
+		require.Equal(t, seen, rec.Header().Get(Header))
+	}
+}
+
+func TestMiddleware_AttachesIDToContextLogger(t *testing.T) {
+	t.Log("Test that the request-scoped logger includes the request ID")
+
+	var buf bytes.Buffer
+	prev := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = prev })
+
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zerolog.Ctx(r.Context()).Info().Msg("hello")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(Header, "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line map[string]string
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "req-1", line["requestId"])
+	require.Equal(t, "hello", line["message"])
+}
+
+func TestMiddleware_DerivesFromContextLogger(t *testing.T) {
+	t.Log("Test that a logger already in the context is the base of the request-scoped logger")
+
+	var buf bytes.Buffer
+	base := zerolog.New(&buf).With().Str("service", "embedded").Logger()
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zerolog.Ctx(r.Context()).Info().Msg("hello")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(Header, "req-2")
+	h.ServeHTTP(httptest.NewRecorder(), req.WithContext(base.WithContext(req.Context())))
+
+	var line map[string]string
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "req-2", line["requestId"])
+	require.Equal(t, "embedded", line["service"])
+}
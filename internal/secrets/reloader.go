@@ -0,0 +1,194 @@
+// reloader.go
+//
+// Reloads every declared secret from the mounted directory and publishes them
+// as a new configuration snapshot. Reloads are all-or-nothing: the directory
+// is read completely and validated before anything is published, so a
+// half-written Secret never partially applies.
+
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+// kubeDataDir is the symlink Kubernetes' atomic writer swaps to publish a new
+// version of a projected volume. Keys in the mount point are symlinks through it.
+const kubeDataDir = "..data"
+
+// Triggers identify what initiated a reload, for logs and metrics.
+const (
+	TriggerWebhook = "webhook"
+	TriggerWatch   = "watch"
+)
+
+// Observer is notified after every reload attempt, successful or not.
+type Observer interface {
+	ObserveSecretReload(trigger string, err error)
+}
+
+// KeyStatus reports the outcome of a reload for one declared secret.
+// It never contains the secret value.
+type KeyStatus struct {
+	Present  bool `json:"present"`
+	Length   int  `json:"length"`
+	Changed  bool `json:"changed"`
+	Required bool `json:"required"`
+}
+
+// Result summarises a successful reload.
+type Result struct {
+	Generation uint64               `json:"generation"`
+	Secrets    map[string]KeyStatus `json:"secrets"`
+	// Ignored lists files found in the directory that the schema does not declare.
+	Ignored []string `json:"ignored,omitempty"`
+}
+
+// MissingError reports required secrets that were absent or empty.
+type MissingError struct {
+	Names []string
+}
+
+func (e *MissingError) Error() string {
+	return "missing required secrets: " + strings.Join(e.Names, ", ")
+}
+
+// Reloader reads the secret directory named by the current snapshot and
+// publishes the declared secrets to the store.
+type Reloader struct {
+	store    *config.Store
+	schema   Schema
+	observer Observer
+}
+
+// NewReloader returns a Reloader for the given store and schema.
+// A nil or empty schema falls back to DefaultSchema.
+func NewReloader(store *config.Store, schema Schema) *Reloader {
+	if len(schema) == 0 {
+		schema = DefaultSchema
+	}
+	return &Reloader{store: store, schema: schema}
+}
+
+// SetObserver registers o to be notified of every reload attempt. It must be
+// called before the Reloader is shared between goroutines.
+func (r *Reloader) SetObserver(o Observer) {
+	r.observer = o
+}
+
+// Schema returns the declared secrets.
+func (r *Reloader) Schema() Schema {
+	return r.schema
+}
+
+// Reload reads all declared secrets and publishes them as a new snapshot.
+// On any error (unreadable directory, missing required key) nothing is
+// published and the current snapshot stays in effect. A *MissingError is
+// returned when validation fails. trigger (TriggerWebhook, TriggerWatch)
+// is passed through to the observer.
+func (r *Reloader) Reload(trigger string) (Result, error) {
+	result, err := r.reload()
+	if r.observer != nil {
+		r.observer.ObserveSecretReload(trigger, err)
+	}
+	return result, err
+}
+
+func (r *Reloader) reload() (Result, error) {
+	dir := r.store.Load().SecretDir
+	files, err := readDir(dir)
+	if err != nil {
+		return Result{}, err
+	}
+
+	values := make(map[string]string, len(r.schema))
+	declared := make(map[string]bool, len(r.schema))
+	var missing []string
+	for _, spec := range r.schema {
+		file := spec.fileName()
+		declared[file] = true
+		v, ok := files[file]
+		if ok && v != "" {
+			values[spec.Name] = v
+		} else if spec.Required {
+			missing = append(missing, spec.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return Result{}, &MissingError{Names: missing}
+	}
+
+	result := Result{Secrets: make(map[string]KeyStatus, len(r.schema))}
+	for file := range files {
+		if !declared[file] {
+			result.Ignored = append(result.Ignored, file)
+		}
+	}
+	sort.Strings(result.Ignored)
+
+	snap, err := r.store.Update(func(next *config.Snapshot) error {
+		for _, spec := range r.schema {
+			v, present := values[spec.Name]
+			result.Secrets[spec.Name] = KeyStatus{
+				Present:  present,
+				Length:   len(v),
+				Changed:  v != next.Secret(spec.Name),
+				Required: spec.Required,
+			}
+		}
+		next.Secrets = values
+		return nil
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	result.Generation = snap.Generation
+	return result, nil
+}
+
+// readDir returns the contents of every regular file in dir, keyed by file
+// name. Kubernetes internals (entries starting with "..") and other dotfiles
+// are skipped. When the directory is a Kubernetes projected volume, keys are
+// read from the resolved ..data target so they all come from one version even
+// if the symlink is swapped mid-read.
+func readDir(dir string) (map[string]string, error) {
+	if target, err := filepath.EvalSymlinks(filepath.Join(dir, kubeDataDir)); err == nil {
+		dir = target
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("resolving %s: %w", kubeDataDir, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading secret directory: %w", err)
+	}
+
+	files := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path) // follows key symlinks
+		if err != nil {
+			return nil, fmt.Errorf("stat %s: %w", name, err)
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		// Trim trailing newlines if present (kube Secret keys are raw bytes)
+		files[name] = strings.TrimRight(string(data), "\r\n")
+	}
+	return files, nil
+}
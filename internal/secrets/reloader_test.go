@@ -0,0 +1,105 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+// makeKubeSecretDir mimics the layout produced by the kubelet's atomic writer:
+// a timestamped directory holding the data, a ..data symlink pointing at it and
+// one symlink per key pointing through ..data.
+func makeKubeSecretDir(t *testing.T, values map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	version := filepath.Join(dir, "..2026_10_17_10_00_00.000000001")
+	require.NoError(t, os.Mkdir(version, 0o755))
+	for k, v := range values {
+		writeFile(t, filepath.Join(version, k), v)
+	}
+	require.NoError(t, os.Symlink(filepath.Base(version), filepath.Join(dir, "..data")))
+	for k := range values {
+		require.NoError(t, os.Symlink(filepath.Join("..data", k), filepath.Join(dir, k)))
+	}
+	return dir
+}
+
+func newStore(dir string, values map[string]string) *config.Store {
+	return config.NewStore(config.Snapshot{SecretDir: dir, Secrets: values})
+}
+
+func TestReload_KubernetesLayout(t *testing.T) {
+	t.Log("Test that keys are read through ..data and kubelet internals are skipped")
+
+	dir := makeKubeSecretDir(t, map[string]string{
+		"FAKE_SECRET": "fake\n",
+		"db-password": "hunter2",
+		"extra":       "unused",
+	})
+	store := newStore(dir, map[string]string{"FAKE_SECRET": "fake"})
+	schema, err := ParseSchema("FAKE_SECRET,db-password=DB_PASSWORD,API_TOKEN?")
+	require.NoError(t, err)
+
+	result, err := NewReloader(store, schema).Reload(TriggerWatch)
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(2), result.Generation)
+	require.Equal(t, []string{"extra"}, result.Ignored)
+	require.Equal(t, KeyStatus{Present: true, Length: 4, Changed: false, Required: true}, result.Secrets["FAKE_SECRET"])
+	require.Equal(t, KeyStatus{Present: true, Length: 7, Changed: true, Required: true}, result.Secrets["DB_PASSWORD"])
+	require.Equal(t, KeyStatus{Present: false, Length: 0, Changed: false, Required: false}, result.Secrets["API_TOKEN"])
+
+	snap := store.Load()
+	require.Equal(t, "hunter2", snap.Secret("DB_PASSWORD"))
+	require.Equal(t, "fake", snap.Secret("FAKE_SECRET"))
+	require.NotContains(t, snap.Secrets, "extra")
+}
+
+func TestReload_MissingRequiredPublishesNothing(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "FAKE_SECRET"), "new")
+	writeFile(t, filepath.Join(dir, "API_TOKEN"), "")
+	store := newStore(dir, map[string]string{"FAKE_SECRET": "old"})
+	schema, err := ParseSchema("FAKE_SECRET,API_TOKEN,DB_PASSWORD")
+	require.NoError(t, err)
+
+	_, err = NewReloader(store, schema).Reload(TriggerWatch)
+
+	var missing *MissingError
+	require.True(t, errors.As(err, &missing))
+	require.Equal(t, []string{"API_TOKEN", "DB_PASSWORD"}, missing.Names)
+	require.Equal(t, "old", store.Load().Secret("FAKE_SECRET"))
+	require.Equal(t, uint64(1), store.Load().Generation)
+}
+
+func TestReload_RemovedOptionalSecretIsReportedChanged(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "FAKE_SECRET"), "same")
+	store := newStore(dir, map[string]string{"FAKE_SECRET": "same", "API_TOKEN": "gone"})
+	schema, err := ParseSchema("FAKE_SECRET,API_TOKEN?")
+	require.NoError(t, err)
+
+	result, err := NewReloader(store, schema).Reload(TriggerWatch)
+	require.NoError(t, err)
+	require.False(t, result.Secrets["FAKE_SECRET"].Changed)
+	require.Equal(t, KeyStatus{Present: false, Changed: true}, result.Secrets["API_TOKEN"])
+	require.Empty(t, store.Load().Secret("API_TOKEN"))
+}
+
+func TestReload_UnreadableDirectory(t *testing.T) {
+	store := newStore(filepath.Join(t.TempDir(), "missing"), nil)
+	_, err := NewReloader(store, nil).Reload(TriggerWatch)
+	require.Error(t, err)
+	require.Equal(t, uint64(1), store.Load().Generation)
+}
@@ -0,0 +1,101 @@
+// schema.go
+//
+// Declares which files in the mounted secret directory map to which named
+// secrets, and which of them must be present for a reload to be accepted.
+
+package secrets
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+// Spec declares one secret expected in the mounted directory.
+type Spec struct {
+	// Name is the logical secret name exposed through the config snapshot.
+	Name string
+	// File is the file name inside the secret directory (the Secret key).
+	// It defaults to Name when empty.
+	File string
+	// Required secrets must be present and non-empty for a reload to apply.
+	Required bool
+}
+
+// fileName returns the file backing the spec.
+func (s Spec) fileName() string {
+	if s.File != "" {
+		return s.File
+	}
+	return s.Name
+}
+
+// Schema is the full set of declared secrets.
+type Schema []Spec
+
+// DefaultSchema declares the single FAKE_SECRET key mounted by the Helm chart,
+// as "FAKE_SECRET?". It is optional because FAKE_SECRET is unset by default,
+// which must not keep /readyz failing; declare it in secrets.schema without
+// the "?" to make it required.
+var DefaultSchema = Schema{{Name: config.FakeSecretName}}
+
+// SchemaValidator reports an invalid secrets.schema when the configuration
+// is loaded, before anything is started.
+var SchemaValidator = config.Validator{
+	Field: "secrets.schema",
+	Check: func(c *config.Config) error {
+		_, err := ParseSchema(c.Secrets.Schema)
+		return err
+	},
+}
+
+// ParseSchema parses a comma-separated schema declaration such as
+// "FAKE_SECRET,API_TOKEN?,db-password=DB_PASSWORD". Each entry names a file;
+// "file=NAME" maps the file to a different secret name and a trailing "?"
+// marks the secret optional. An empty declaration yields DefaultSchema.
+func ParseSchema(raw string) (Schema, error) {
+	if strings.TrimSpace(raw) == "" {
+		return DefaultSchema, nil
+	}
+
+	var schema Schema
+	names := map[string]bool{}
+	files := map[string]bool{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		spec := Spec{Required: true}
+		if strings.HasSuffix(entry, "?") {
+			spec.Required = false
+			entry = strings.TrimSuffix(entry, "?")
+		}
+		file, name := entry, entry
+		if i := strings.IndexByte(entry, '='); i >= 0 {
+			file, name = strings.TrimSpace(entry[:i]), strings.TrimSpace(entry[i+1:])
+		}
+		if file == "" || name == "" {
+			return nil, fmt.Errorf("invalid secret schema entry %q", entry)
+		}
+		if strings.HasPrefix(file, ".") || strings.ContainsAny(file, `/\`) {
+			return nil, fmt.Errorf("invalid secret file name %q", file)
+		}
+		if names[name] || files[file] {
+			return nil, fmt.Errorf("duplicate secret schema entry %q", entry)
+		}
+		names[name], files[file] = true, true
+
+		spec.Name = name
+		if file != name {
+			spec.File = file
+		}
+		schema = append(schema, spec)
+	}
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("secret schema %q declares no secrets", raw)
+	}
+	return schema, nil
+}
@@ -0,0 +1,44 @@
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+func TestParseSchema(t *testing.T) {
+	schema, err := ParseSchema(" FAKE_SECRET, API_TOKEN? ,db-password=DB_PASSWORD")
+	require.NoError(t, err)
+	require.Equal(t, Schema{
+		{Name: "FAKE_SECRET", Required: true},
+		{Name: "API_TOKEN", Required: false},
+		{Name: "DB_PASSWORD", File: "db-password", Required: true},
+	}, schema)
+}
+
+func TestParseSchema_EmptyUsesDefault(t *testing.T) {
+	schema, err := ParseSchema("  ")
+	require.NoError(t, err)
+	require.Equal(t, DefaultSchema, schema)
+}
+
+func TestParseSchema_Invalid(t *testing.T) {
+	for _, raw := range []string{",", "=NAME", "file=", "..data", "a/b", "A,A", "x=A,y=A"} {
+		_, err := ParseSchema(raw)
+		require.Error(t, err, raw)
+	}
+}
+
+func TestSchemaValidator(t *testing.T) {
+	t.Log("Test that an invalid schema fails configuration loading")
+
+	_, _, err := config.Load(nil, func(key string) (string, bool) {
+		if key == "SECRET_SCHEMA" {
+			return "A,A", true
+		}
+		return "", false
+	}, SchemaValidator)
+	require.ErrorContains(t, err, "secrets.schema")
+}
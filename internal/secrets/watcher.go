@@ -0,0 +1,206 @@
+// watcher.go
+//
+// Watches the mounted secret directory and triggers the same all-or-nothing
+// reload as POST /-/reload whenever its contents change, removing the need
+// for a configmap-reload sidecar. inotify (via fsnotify) is used where
+// available, with a polling fallback for filesystems or platforms where it is
+// not. Bursts of events, such as the kubelet's atomic ..data symlink swap,
+// are debounced into a single reload.
+
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/logctx"
+)
+
+// Defaults for WatchOptions.
+const (
+	DefaultDebounce     = 500 * time.Millisecond
+	DefaultPollInterval = 10 * time.Second
+)
+
+// WatchOptions configures the secret directory watcher.
+type WatchOptions struct {
+	// Enabled turns the watcher on.
+	Enabled bool
+	// Debounce is how long the directory must stay quiet before reloading.
+	Debounce time.Duration
+	// PollInterval is how often the directory is fingerprinted when inotify
+	// is unavailable (or when ForcePolling is set).
+	PollInterval time.Duration
+	// ForcePolling skips inotify entirely.
+	ForcePolling bool
+}
+
+// WatchOptionsFromConfig builds WatchOptions from the secrets configuration.
+func WatchOptionsFromConfig(cfg config.Secrets) WatchOptions {
+	return WatchOptions{
+		Enabled:      cfg.Watch,
+		Debounce:     cfg.WatchDebounce,
+		PollInterval: cfg.WatchPollInterval,
+		ForcePolling: cfg.WatchPoll,
+	}
+}
+
+// Watcher reloads secrets whenever the secret directory changes.
+type Watcher struct {
+	reloader *Reloader
+	opts     WatchOptions
+}
+
+// NewWatcher returns a Watcher driving reloader. Zero durations in opts are
+// replaced with the package defaults.
+func NewWatcher(reloader *Reloader, opts WatchOptions) *Watcher {
+	if opts.Debounce == 0 {
+		opts.Debounce = DefaultDebounce
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	return &Watcher{reloader: reloader, opts: opts}
+}
+
+// Run watches the secret directory until ctx is cancelled. If the directory
+// exists when Run starts, an initial reload applies the mounted values.
+// Events are logged to the zerolog logger in ctx, if any.
+func (w *Watcher) Run(ctx context.Context) {
+	dir := w.reloader.store.Load().SecretDir
+	logger := logctx.From(ctx).With().Str("component", "secret-watcher").Str("dir", dir).Logger()
+
+	if _, err := os.Stat(dir); err == nil {
+		w.reload(&logger)
+	} else {
+		logger.Info().Msg("Secret directory not present yet; waiting for it to appear")
+	}
+
+	if !w.opts.ForcePolling {
+		err := w.watchNotify(ctx, dir, &logger)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		logger.Warn().Err(err).Dur("interval", w.opts.PollInterval).Msg("inotify unavailable; falling back to polling")
+	}
+	w.poll(ctx, dir, &logger)
+}
+
+// watchNotify uses fsnotify until ctx is cancelled. It returns an error when
+// inotify cannot be used or the watched directory itself disappears.
+func (w *Watcher) watchNotify(ctx context.Context, dir string, logger *zerolog.Logger) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+	if err := fw.Add(dir); err != nil {
+		return err
+	}
+	logger.Info().Msg("Watching secret directory with inotify")
+
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return errors.New("fsnotify event channel closed")
+			}
+			if filepath.Clean(ev.Name) == filepath.Clean(dir) && ev.Has(fsnotify.Remove|fsnotify.Rename) {
+				return errors.New("secret directory removed")
+			}
+			// Any change (including the ..data symlink swap) schedules a reload.
+			debounce.Reset(w.opts.Debounce)
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return errors.New("fsnotify error channel closed")
+			}
+			logger.Warn().Err(err).Msg("Secret watcher error")
+		case <-debounce.C:
+			w.reload(logger)
+		}
+	}
+}
+
+// poll fingerprints the directory every PollInterval and reloads (after the
+// debounce delay) when the fingerprint changes.
+func (w *Watcher) poll(ctx context.Context, dir string, logger *zerolog.Logger) {
+	ticker := time.NewTicker(w.opts.PollInterval)
+	defer ticker.Stop()
+
+	last := fingerprint(dir)
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if fp := fingerprint(dir); fp != last {
+				last = fp
+				pending = time.After(w.opts.Debounce)
+			}
+		case <-pending:
+			pending = nil
+			w.reload(logger)
+		}
+	}
+}
+
+// reload runs one reload and logs its outcome; metrics are recorded by the
+// reloader's observer.
+func (w *Watcher) reload(logger *zerolog.Logger) {
+	result, err := w.reloader.Reload(TriggerWatch)
+	if err != nil {
+		logger.Error().Err(err).Str("trigger", TriggerWatch).Msg("Secret reload failed; keeping previous values")
+		return
+	}
+	logger.Info().
+		Str("trigger", TriggerWatch).
+		Uint64("generation", result.Generation).
+		Interface("secrets", result.Secrets).
+		Strs("ignored", result.Ignored).
+		Msg("secrets reloaded from files")
+}
+
+// fingerprint summarises the directory's visible state: the ..data target
+// plus name, size and modification time of every entry. An unreadable
+// directory yields "".
+func fingerprint(dir string) string {
+	var b strings.Builder
+	if target, err := os.Readlink(filepath.Join(dir, kubeDataDir)); err == nil {
+		b.WriteString(kubeDataDir + "->" + target + "\n")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return b.String()
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			fmt.Fprintf(&b, "%s:?\n", name)
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d\n", name, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
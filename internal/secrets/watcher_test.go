@@ -0,0 +1,133 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+// recordingObserver counts reload attempts per trigger.
+type recordingObserver struct {
+	mu       sync.Mutex
+	success  int
+	failures int
+}
+
+func (o *recordingObserver) ObserveSecretReload(trigger string, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err != nil {
+		o.failures++
+		return
+	}
+	o.success++
+}
+
+func (o *recordingObserver) counts() (int, int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.success, o.failures
+}
+
+// startWatcher runs a watcher in the background for the duration of the test.
+func startWatcher(t *testing.T, store *config.Store, opts WatchOptions) *recordingObserver {
+	t.Helper()
+
+	reloader := NewReloader(store, nil)
+	obs := &recordingObserver{}
+	reloader.SetObserver(obs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewWatcher(reloader, opts).Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return obs
+}
+
+func eventuallySecret(t *testing.T, store *config.Store, want string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return store.Load().Secret(config.FakeSecretName) == want
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWatcher_InitialReloadAndFileWrite(t *testing.T) {
+	t.Log("Test that the watcher applies mounted values at start and on change")
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "FAKE_SECRET"), "one")
+	store := newStore(dir, nil)
+
+	obs := startWatcher(t, store, WatchOptions{Enabled: true, Debounce: 20 * time.Millisecond})
+	eventuallySecret(t, store, "one")
+
+	writeFile(t, filepath.Join(dir, "FAKE_SECRET"), "two")
+	eventuallySecret(t, store, "two")
+
+	success, failures := obs.counts()
+	require.GreaterOrEqual(t, success, 2)
+	require.Zero(t, failures)
+}
+
+func TestWatcher_KubernetesSymlinkSwap(t *testing.T) {
+	t.Log("Test that the kubelet's atomic ..data swap triggers a single consistent reload")
+
+	dir := makeKubeSecretDir(t, map[string]string{"FAKE_SECRET": "v1"})
+	store := newStore(dir, nil)
+	startWatcher(t, store, WatchOptions{Enabled: true, Debounce: 20 * time.Millisecond})
+	eventuallySecret(t, store, "v1")
+
+	// Reproduce the atomic writer: new version dir, temp symlink, rename over ..data.
+	version := filepath.Join(dir, "..2026_10_17_11_00_00.000000002")
+	require.NoError(t, os.Mkdir(version, 0o755))
+	writeFile(t, filepath.Join(version, "FAKE_SECRET"), "v2")
+	require.NoError(t, os.Symlink(filepath.Base(version), filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+
+	eventuallySecret(t, store, "v2")
+}
+
+func TestWatcher_PollingFallback(t *testing.T) {
+	t.Log("Test that polling detects a directory that appears after startup")
+
+	dir := filepath.Join(t.TempDir(), "later")
+	store := newStore(dir, nil)
+	obs := startWatcher(t, store, WatchOptions{
+		Enabled:      true,
+		ForcePolling: true,
+		Debounce:     10 * time.Millisecond,
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	require.NoError(t, os.Mkdir(dir, 0o755))
+	writeFile(t, filepath.Join(dir, "FAKE_SECRET"), "polled")
+	eventuallySecret(t, store, "polled")
+
+	_, failures := obs.counts()
+	// A reload may race the file write and fail validation once; it must
+	// never publish a partial value.
+	require.LessOrEqual(t, failures, 1)
+}
+
+func TestWatchOptionsFromConfig(t *testing.T) {
+	opts := WatchOptionsFromConfig(config.Default().Secrets)
+	require.Equal(t, WatchOptions{Enabled: true, Debounce: DefaultDebounce, PollInterval: DefaultPollInterval}, opts)
+
+	opts = WatchOptionsFromConfig(config.Secrets{WatchPoll: true, WatchDebounce: 2 * time.Second})
+	require.False(t, opts.Enabled)
+	require.True(t, opts.ForcePolling)
+	require.Equal(t, 2*time.Second, opts.Debounce)
+}
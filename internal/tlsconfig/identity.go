@@ -0,0 +1,67 @@
+// identity.go
+//
+// Exposes the verified client certificate of a mutual TLS connection to
+// handlers and request logs.
+
+package tlsconfig
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/paulcapestany/toy-service/internal/logctx"
+)
+
+// Identity describes a verified client certificate.
+type Identity struct {
+	Subject      string   `json:"subject"`
+	CommonName   string   `json:"commonName"`
+	DNSNames     []string `json:"dnsNames,omitempty"`
+	URIs         []string `json:"uris,omitempty"`
+	SerialNumber string   `json:"serialNumber"`
+}
+
+type contextKey struct{}
+
+// Middleware stores the verified client identity, if any, in the request
+// context and adds it to the request-scoped logger as clientCN. Install it
+// after requestid.Middleware.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := identityFromRequest(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), contextKey{}, id)
+		logger := logctx.From(ctx).With().Str("clientCN", id.CommonName).Logger()
+		ctx = logger.WithContext(ctx)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ClientIdentity returns the verified client identity stored by Middleware.
+func ClientIdentity(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(Identity)
+	return id, ok
+}
+
+// identityFromRequest reads the leaf of the first verified chain. Unverified
+// certificates (possible with optional client auth) are ignored.
+func identityFromRequest(r *http.Request) (Identity, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return Identity{}, false
+	}
+	leaf := r.TLS.VerifiedChains[0][0]
+	id := Identity{
+		Subject:      leaf.Subject.String(),
+		CommonName:   leaf.Subject.CommonName,
+		DNSNames:     leaf.DNSNames,
+		SerialNumber: leaf.SerialNumber.String(),
+	}
+	for _, u := range leaf.URIs {
+		id.URIs = append(id.URIs, u.String())
+	}
+	return id, true
+}
@@ -0,0 +1,216 @@
+// tlsconfig.go
+//
+// Builds the server's TLS configuration from mounted certificate files and
+// keeps it current: the certificate, key and optional client CA bundle are
+// re-read when their contents change, so cert-manager rotations apply without
+// a restart. When a client CA bundle is configured, clients are verified
+// against it (mutual TLS).
+
+package tlsconfig
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/logctx"
+)
+
+// DefaultReloadInterval is how often the certificate files are checked.
+const DefaultReloadInterval = 10 * time.Second
+
+// Options configures TLS serving. TLS is enabled when CertFile and KeyFile
+// are set.
+type Options struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enables client certificate verification against the PEM
+	// bundle it names.
+	ClientCAFile string
+	// ClientAuth is tls.RequireAndVerifyClientCert or
+	// tls.VerifyClientCertIfGiven; it only applies with ClientCAFile.
+	ClientAuth tls.ClientAuthType
+	// ReloadInterval is how often the files are checked for changes.
+	ReloadInterval time.Duration
+}
+
+// Enabled reports whether TLS should be served.
+func (o Options) Enabled() bool {
+	return o.CertFile != "" && o.KeyFile != ""
+}
+
+// OptionsFromConfig builds Options from the TLS configuration. ClientAuth
+// is "require" (the default) or "optional".
+func OptionsFromConfig(cfg config.TLS) (Options, error) {
+	opts := Options{
+		CertFile:       cfg.CertFile,
+		KeyFile:        cfg.KeyFile,
+		ClientCAFile:   cfg.ClientCAFile,
+		ClientAuth:     tls.RequireAndVerifyClientCert,
+		ReloadInterval: cfg.ReloadInterval,
+	}
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return Options{}, errors.New("TLS certificate and key files must be set together")
+	}
+	if opts.ClientCAFile != "" && !opts.Enabled() {
+		return Options{}, errors.New("TLS client CA file requires a certificate and key")
+	}
+
+	switch mode := strings.ToLower(cfg.ClientAuth); mode {
+	case "", "require":
+	case "optional":
+		opts.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return Options{}, fmt.Errorf("invalid TLS client auth %q (want require or optional)", mode)
+	}
+
+	if opts.ReloadInterval <= 0 {
+		opts.ReloadInterval = DefaultReloadInterval
+	}
+	return opts, nil
+}
+
+// material is one consistent set of loaded credentials.
+type material struct {
+	cert        *tls.Certificate
+	clientCAs   *x509.CertPool
+	fingerprint string
+}
+
+// Reloader serves the current certificate and client CA bundle.
+type Reloader struct {
+	opts    Options
+	current atomic.Pointer[material]
+}
+
+// NewReloader loads the configured files, failing if they are unusable.
+func NewReloader(opts Options) (*Reloader, error) {
+	if opts.ReloadInterval <= 0 {
+		opts.ReloadInterval = DefaultReloadInterval
+	}
+	r := &Reloader{opts: opts}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload re-reads the files if their contents changed and reports whether new
+// material was installed. On error the previous material stays in use.
+func (r *Reloader) Reload() (bool, error) {
+	files := map[string][]byte{}
+	paths := []string{r.opts.CertFile, r.opts.KeyFile}
+	if r.opts.ClientCAFile != "" {
+		paths = append(paths, r.opts.ClientCAFile)
+	}
+	h := sha256.New()
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+		files[path] = data
+		h.Write(data)
+	}
+	fp := hex.EncodeToString(h.Sum(nil))
+	if cur := r.current.Load(); cur != nil && cur.fingerprint == fp {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(files[r.opts.CertFile], files[r.opts.KeyFile])
+	if err != nil {
+		return false, fmt.Errorf("load TLS key pair: %w", err)
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return false, fmt.Errorf("parse TLS certificate: %w", err)
+		}
+	}
+
+	next := &material{cert: &cert, fingerprint: fp}
+	if r.opts.ClientCAFile != "" {
+		next.clientCAs = x509.NewCertPool()
+		if !next.clientCAs.AppendCertsFromPEM(files[r.opts.ClientCAFile]) {
+			return false, fmt.Errorf("no certificates found in %s", r.opts.ClientCAFile)
+		}
+	}
+
+	r.current.Store(next)
+	return true, nil
+}
+
+// LogCertificate logs the certificate currently being served.
+func (r *Reloader) LogCertificate(logger *zerolog.Logger) {
+	cur := r.current.Load()
+	logger.Info().
+		Str("subject", cur.cert.Leaf.Subject.String()).
+		Time("notAfter", cur.cert.Leaf.NotAfter).
+		Bool("clientAuth", cur.clientCAs != nil).
+		Msg("TLS certificate loaded")
+}
+
+// Run checks the files every ReloadInterval until ctx is cancelled, logging
+// to the zerolog logger in ctx, if any. Kubernetes Secret mounts swap files
+// atomically, so a changed fingerprint always refers to a complete set.
+func (r *Reloader) Run(ctx context.Context) {
+	logger := logctx.From(ctx)
+	ticker := time.NewTicker(r.opts.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.Reload()
+			switch {
+			case err != nil:
+				logger.Error().Err(err).Msg("TLS reload failed; keeping previous certificate")
+			case changed:
+				r.LogCertificate(logger)
+			}
+		}
+	}
+}
+
+// Certificate returns the certificate currently being served.
+func (r *Reloader) Certificate() *tls.Certificate {
+	return r.current.Load().cert
+}
+
+// TLSConfig returns a server configuration that always uses the most
+// recently loaded material.
+func (r *Reloader) TLSConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// Listed explicitly because per-handshake configs below replace the
+		// server's defaults.
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.Certificate(), nil
+		},
+	}
+	if r.opts.ClientCAFile == "" {
+		return base
+	}
+
+	cfg := base.Clone()
+	cfg.ClientAuth = r.opts.ClientAuth
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c := base.Clone()
+		c.ClientAuth = r.opts.ClientAuth
+		c.ClientCAs = r.current.Load().clientCAs
+		return c, nil
+	}
+	return cfg
+}
@@ -0,0 +1,196 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+// testCA issues certificates for tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns PEM-encoded certificate and key for cn.
+func (ca *testCA) issue(t *testing.T, cn string, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+// serve starts an HTTPS server on loopback reporting the client identity.
+func serve(t *testing.T, r *Reloader) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{
+		TLSConfig: r.TLSConfig(),
+		Handler: Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id, _ := ClientIdentity(req.Context())
+			_ = json.NewEncoder(w).Encode(id)
+		})),
+	}
+	go func() { _ = srv.ServeTLS(ln, "", "") }()
+	t.Cleanup(func() { _ = srv.Close() })
+	return "https://" + ln.Addr().String()
+}
+
+func client(ca *testCA, cert *tls.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	cfg := &tls.Config{RootCAs: pool}
+	if cert != nil {
+		cfg.Certificates = []tls.Certificate{*cert}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg, ForceAttemptHTTP2: true}}
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	opts, err := OptionsFromConfig(config.TLS{})
+	require.NoError(t, err)
+	require.False(t, opts.Enabled())
+	require.Equal(t, DefaultReloadInterval, opts.ReloadInterval)
+
+	_, err = OptionsFromConfig(config.TLS{CertFile: "tls.crt"})
+	require.Error(t, err, "key file missing")
+
+	opts, err = OptionsFromConfig(config.TLS{
+		CertFile:       "tls.crt",
+		KeyFile:        "tls.key",
+		ClientCAFile:   "ca.crt",
+		ClientAuth:     "optional",
+		ReloadInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	require.True(t, opts.Enabled())
+	require.Equal(t, tls.VerifyClientCertIfGiven, opts.ClientAuth)
+	require.Equal(t, time.Minute, opts.ReloadInterval)
+
+	_, err = OptionsFromConfig(config.TLS{CertFile: "tls.crt", KeyFile: "tls.key", ClientAuth: "sometimes"})
+	require.Error(t, err)
+}
+
+func TestReloader_MutualTLSAndRotation(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	opts := Options{
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	certPEM, keyPEM := ca.issue(t, "server", 10, x509.ExtKeyUsageServerAuth)
+	writeFile(t, opts.CertFile, certPEM)
+	writeFile(t, opts.KeyFile, keyPEM)
+	writeFile(t, opts.ClientCAFile, ca.pem)
+
+	r, err := NewReloader(opts)
+	require.NoError(t, err)
+	url := serve(t, r)
+
+	t.Log("Test that clients without a certificate are rejected")
+	_, err = client(ca, nil).Get(url)
+	require.Error(t, err)
+
+	t.Log("Test that a verified client identity reaches the handler over HTTP/2")
+	clientCertPEM, clientKeyPEM := ca.issue(t, "toy-web", 20, x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	require.NoError(t, err)
+	resp, err := client(ca, &clientCert).Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, 2, resp.ProtoMajor)
+	require.Equal(t, big.NewInt(10), resp.TLS.PeerCertificates[0].SerialNumber)
+	var id Identity
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&id))
+	require.Equal(t, "toy-web", id.CommonName)
+	require.Equal(t, "20", id.SerialNumber)
+
+	t.Log("Test that unchanged files are not reloaded and rotated files are")
+	changed, err := r.Reload()
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	certPEM, keyPEM = ca.issue(t, "server", 11, x509.ExtKeyUsageServerAuth)
+	writeFile(t, opts.CertFile, certPEM)
+	writeFile(t, opts.KeyFile, keyPEM)
+	changed, err = r.Reload()
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	resp, err = client(ca, &clientCert).Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, big.NewInt(11), resp.TLS.PeerCertificates[0].SerialNumber)
+
+	t.Log("Test that a broken rotation keeps the previous certificate")
+	writeFile(t, opts.KeyFile, []byte("garbage"))
+	_, err = r.Reload()
+	require.Error(t, err)
+	require.Equal(t, big.NewInt(11), r.Certificate().Leaf.SerialNumber)
+}
+
+func TestNewReloader_InvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewReloader(Options{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: filepath.Join(dir, "missing.key")})
+	require.Error(t, err)
+}
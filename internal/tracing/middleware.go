@@ -0,0 +1,69 @@
+// middleware.go
+//
+// HTTP middleware that joins inbound W3C trace context and records a server
+// span per request. The trace and span IDs are added to the request-scoped
+// zerolog logger so handler log lines link to their trace.
+
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/paulcapestany/toy-service/internal/logctx"
+)
+
+// instrumentationName identifies spans created by this package.
+const instrumentationName = "github.com/paulcapestany/toy-service/internal/tracing"
+
+// Middleware extracts traceparent/tracestate from the request, starts a server
+// span from p and names it "<METHOD> <route pattern>" once chi has resolved the
+// route. Install it after requestid.Middleware so the enriched logger keeps the
+// request ID.
+func (p *Provider) Middleware(next http.Handler) http.Handler {
+	tracer := p.TracerProvider.Tracer(instrumentationName)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := p.Propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.UserAgentOriginal(r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.IsValid() {
+			logger := logctx.From(ctx).With().
+				Str("traceId", sc.TraceID().String()).
+				Str("spanId", sc.SpanID().String()).
+				Logger()
+			ctx = logger.WithContext(ctx)
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if route := rctx.RoutePattern(); route != "" {
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(semconv.HTTPRoute(route))
+			}
+		}
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
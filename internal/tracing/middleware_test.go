@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	inboundTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	inboundSpanID  = "00f067aa0ba902b7"
+)
+
+// useRecorder returns a Provider recording spans in memory.
+func useRecorder(t *testing.T) (*Provider, *tracetest.SpanRecorder) {
+	t.Helper()
+
+	rec := tracetest.NewSpanRecorder()
+	return &Provider{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)),
+		Propagator:     propagation.TraceContext{},
+	}, rec
+}
+
+func TestMiddleware_JoinsInboundTraceAndNamesSpanByRoute(t *testing.T) {
+	t.Log("Test that traceparent is honoured and spans are named after the chi route")
+
+	p, rec := useRecorder(t)
+
+	r := chi.NewRouter()
+	r.Use(p.Middleware)
+	r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/7", nil)
+	req.Header.Set("traceparent", "00-"+inboundTraceID+"-"+inboundSpanID+"-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	require.Equal(t, "GET /items/{id}", span.Name())
+	require.Equal(t, inboundTraceID, span.SpanContext().TraceID().String())
+	require.Equal(t, inboundSpanID, span.Parent().SpanID().String())
+	require.True(t, span.Parent().IsRemote())
+}
+
+func TestMiddleware_MarksServerErrors(t *testing.T) {
+	t.Log("Test that 5xx responses set an error status on the span")
+
+	p, rec := useRecorder(t)
+
+	r := chi.NewRouter()
+	r.Use(p.Middleware)
+	r.Get("/boom", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+func TestMiddleware_InjectsIDsIntoLogger(t *testing.T) {
+	t.Log("Test that handler log lines carry traceId and spanId")
+
+	p, rec := useRecorder(t)
+
+	var buf bytes.Buffer
+	prev := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = prev })
+
+	h := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zerolog.Ctx(r.Context()).Info().Msg("inside handler")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+
+	var line map[string]string
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, spans[0].SpanContext().TraceID().String(), line["traceId"])
+	require.Equal(t, spans[0].SpanContext().SpanID().String(), line["spanId"])
+}
+
+func TestMiddleware_IndependentProviders(t *testing.T) {
+	t.Log("Test that each Provider records its own spans without touching the globals")
+
+	first, firstRec := useRecorder(t)
+	second, secondRec := useRecorder(t)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	first.Middleware(h).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	second.Middleware(h).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	second.Middleware(h).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Len(t, firstRec.Ended(), 1)
+	require.Len(t, secondRec.Ended(), 2)
+	require.NotEqual(t, first.TracerProvider, otel.GetTracerProvider())
+}
@@ -0,0 +1,186 @@
+// tracing.go
+//
+// Configures OpenTelemetry distributed tracing. Setup builds a tracer
+// provider with the exporter selected by configuration (OTLP/HTTP for a
+// collector, stdout or a file for local runs) and the W3C tracecontext/baggage
+// propagators. Its Middleware creates one server span per request, named after
+// the matched chi route.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+// Supported values for Config.Exporter.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Config selects the span exporter and describes the service emitting spans.
+type Config struct {
+	// Exporter is one of ExporterNone, ExporterOTLP, ExporterStdout or ExporterFile.
+	Exporter string
+	// Endpoint is the OTLP/HTTP collector endpoint (host:port or full URL).
+	// When empty the exporter falls back to the standard OTEL_EXPORTER_OTLP_*
+	// environment variables, defaulting to localhost:4318.
+	Endpoint string
+	// FilePath is where ExporterFile writes newline-delimited JSON spans.
+	FilePath string
+
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+}
+
+// FromConfig builds a Config from the tracing section. "console", the name
+// used by other OpenTelemetry SDKs, is accepted for ExporterStdout.
+func FromConfig(cfg config.Tracing) Config {
+	exporter := strings.ToLower(strings.TrimSpace(cfg.Exporter))
+	if exporter == "" {
+		exporter = ExporterNone
+	}
+	if exporter == "console" {
+		exporter = ExporterStdout
+	}
+
+	return Config{
+		Exporter: exporter,
+		Endpoint: cfg.Endpoint,
+		FilePath: cfg.File,
+	}
+}
+
+// Provider is the tracer provider and propagators built by Setup. It is
+// not installed globally, so several servers in one process can each trace
+// with their own; only the binary makes it the process-wide default.
+type Provider struct {
+	// TracerProvider creates the spans; a no-op provider with ExporterNone.
+	TracerProvider trace.TracerProvider
+	// Propagator extracts and injects W3C tracecontext and baggage.
+	Propagator propagation.TextMapPropagator
+
+	shutdown func(context.Context) error
+}
+
+// Setup builds a Provider with the exporter selected by cfg and the W3C
+// propagators. With ExporterNone only propagation is configured so inbound
+// trace context is still honoured and forwarded in logs.
+func Setup(ctx context.Context, cfg Config) (*Provider, error) {
+	p := &Provider{
+		TracerProvider: trace.NewNoopTracerProvider(),
+		Propagator: propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+		shutdown: func(context.Context) error { return nil },
+	}
+
+	var (
+		exporter sdktrace.SpanExporter
+		closer   io.Closer
+		err      error
+	)
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return p, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		opts, err = otlpOptions(cfg.Endpoint)
+		if err == nil {
+			exporter, err = otlptracehttp.New(ctx, opts...)
+		}
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		var f *os.File
+		f, err = os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err == nil {
+			closer = f
+			exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+		}
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+		semconv.DeploymentEnvironment(cfg.Environment),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("building trace resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	p.TracerProvider = tp
+	p.shutdown = func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}
+	return p, nil
+}
+
+// Shutdown flushes pending spans and releases exporter resources; it must be
+// called during shutdown.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.shutdown(ctx)
+}
+
+// otlpOptions translates a configured endpoint into exporter options. A full
+// URL selects scheme and path; a bare host:port is treated as plaintext since
+// collectors are normally reached over the pod or node network.
+func otlpOptions(endpoint string) ([]otlptracehttp.Option, error) {
+	if endpoint == "" {
+		return nil, nil
+	}
+	if !strings.Contains(endpoint, "://") {
+		return []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(endpoint),
+			otlptracehttp.WithInsecure(),
+		}, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing OTLP endpoint: %w", err)
+	}
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if u.Path != "" && u.Path != "/" {
+		opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+	}
+	return opts, nil
+}
@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+func TestFromConfig(t *testing.T) {
+	t.Run("defaultsToNone", func(t *testing.T) {
+		require.Equal(t, ExporterNone, FromConfig(config.Tracing{}).Exporter)
+	})
+
+	t.Run("consoleAliasesStdout", func(t *testing.T) {
+		require.Equal(t, ExporterStdout, FromConfig(config.Tracing{Exporter: " Console "}).Exporter)
+	})
+
+	t.Run("copiesEndpointAndFile", func(t *testing.T) {
+		cfg := FromConfig(config.Tracing{Exporter: "otlp", Endpoint: "collector:4318", File: "spans.json"})
+		require.Equal(t, Config{Exporter: ExporterOTLP, Endpoint: "collector:4318", FilePath: "spans.json"}, cfg)
+	})
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), Config{Exporter: "zipkin"})
+	require.Error(t, err)
+}
+
+func TestSetup_FileExporterWritesSpans(t *testing.T) {
+	t.Log("Test that the file exporter flushes spans on shutdown")
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+	p, err := Setup(context.Background(), Config{
+		Exporter:       ExporterFile,
+		FilePath:       path,
+		ServiceName:    "toy-service",
+		ServiceVersion: "test",
+		Environment:    "test",
+	})
+	require.NoError(t, err)
+
+	p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/echo", nil))
+
+	require.NoError(t, p.Shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"Name":"GET"`)
+	require.Contains(t, string(data), "toy-service")
+}
@@ -0,0 +1,359 @@
+// client.go
+//
+// A typed Go client for the toy-service public API. It is written by hand
+// against spec/openapi.yaml and kept in step with it by drift_test.go.
+// Every method takes a context, retries 429 and 5xx responses (and
+// transport errors) with jittered exponential backoff, forwards the
+// caller's request ID and returns *Error for error responses.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestIDHeader carries the request ID to and from the server.
+const RequestIDHeader = "X-Request-Id"
+
+// DefaultUserAgent is sent unless WithUserAgent overrides it.
+const DefaultUserAgent = "toy-service-go-client"
+
+// maxDrainBody caps how much of an unread response body is discarded to keep
+// its connection; longer bodies are cheaper to abandon with the connection.
+const maxDrainBody = 256 << 10
+
+// apiPrefix is the version of the API the client calls. The unversioned
+// paths are deprecated aliases.
+const apiPrefix = "/v1"
+
+// RetryPolicy controls retries of 429 and 5xx responses and transport
+// errors. Every toy-service operation is safe to repeat, POST /echo
+// included.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first;
+	// 1 disables retries.
+	MaxAttempts int
+	// MinBackoff bounds the wait before the first retry. The bound doubles
+	// with each retry up to MaxBackoff, and the actual wait is drawn from
+	// the upper half of it.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy makes up to three attempts.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  100 * time.Millisecond,
+	MaxBackoff:  2 * time.Second,
+}
+
+// Client calls the toy-service API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	retry      RetryPolicy
+	userAgent  string
+	requestID  func(context.Context) string
+
+	// sleep waits between attempts; tests replace it.
+	sleep func(context.Context, time.Duration) error
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests (default
+// http.DefaultClient). Use it for timeouts, TLS and transports.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) { c.retry = p }
+}
+
+// WithUserAgent sets the User-Agent header.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// WithRequestIDFunc sets how the request ID is taken from a call's context
+// (default RequestIDFromContext), e.g. to forward the ID assigned by a
+// service's own middleware. An empty ID lets the server generate one.
+func WithRequestIDFunc(fn func(context.Context) string) Option {
+	return func(c *Client) { c.requestID = fn }
+}
+
+// New returns a client for the service at baseURL, e.g.
+// "http://toy-service:8080". A path in baseURL is kept as a prefix.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("client: base URL %q must be an absolute http or https URL", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawQuery, u.Fragment = "", ""
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		retry:      DefaultRetryPolicy,
+		userAgent:  DefaultUserAgent,
+		requestID:  RequestIDFromContext,
+		sleep:      sleep,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.retry.MaxAttempts < 1 {
+		c.retry.MaxAttempts = 1
+	}
+	return c, nil
+}
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a context whose calls send id as
+// X-Request-Id, so the server's logs can be correlated with the caller's.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the ID set by ContextWithRequestID.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Echo calls POST /v1/echo.
+func (c *Client) Echo(ctx context.Context, message string) (*EchoResponse, error) {
+	var out EchoResponse
+	if err := c.do(ctx, http.MethodPost, "/echo", nil, EchoRequest{Message: message}, &out, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Info calls GET /v1/info.
+func (c *Client) Info(ctx context.Context) (*InfoResponse, error) {
+	var out InfoResponse
+	if err := c.do(ctx, http.MethodGet, "/info", nil, nil, &out, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Version calls GET /v1/version.
+func (c *Client) Version(ctx context.Context) (*VersionResponse, error) {
+	var out VersionResponse
+	if err := c.do(ctx, http.MethodGet, "/version", nil, nil, &out, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Healthz calls the legacy GET /v1/healthz probe.
+func (c *Client) Healthz(ctx context.Context) (*HealthResponse, error) {
+	var out HealthResponse
+	if err := c.do(ctx, http.MethodGet, "/healthz", nil, nil, &out, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Livez calls GET /v1/livez. A failing probe (503) is not an error: check
+// HealthReport.OK. verbose asks for per-check results.
+func (c *Client) Livez(ctx context.Context, verbose bool) (*HealthReport, error) {
+	return c.probe(ctx, "/livez", verbose)
+}
+
+// Readyz calls GET /readyz; see Livez.
+func (c *Client) Readyz(ctx context.Context, verbose bool) (*HealthReport, error) {
+	return c.probe(ctx, "/readyz", verbose)
+}
+
+// Startupz calls GET /startupz; see Livez.
+func (c *Client) Startupz(ctx context.Context, verbose bool) (*HealthReport, error) {
+	return c.probe(ctx, "/startupz", verbose)
+}
+
+func (c *Client) probe(ctx context.Context, path string, verbose bool) (*HealthReport, error) {
+	var query url.Values
+	if verbose {
+		query = url.Values{"verbose": {""}}
+	}
+	var out HealthReport
+	// A failing probe answers 503 with a report; that is the result, not a
+	// reason to retry.
+	if err := c.do(ctx, http.MethodGet, path, query, nil, &out, http.StatusOK, http.StatusServiceUnavailable); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// do sends the request, retrying per the policy, and decodes a response
+// with one of the expected statuses into out.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any, expected ...int) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("client: encode request: %w", err)
+		}
+	}
+	u := *c.baseURL
+	u.Path += apiPrefix + path
+	u.RawQuery = query.Encode()
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, method, u.String(), body)
+		if err != nil {
+			if ctx.Err() != nil || attempt >= c.retry.MaxAttempts {
+				return err
+			}
+			if err := c.sleep(ctx, c.backoff(attempt)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if contains(expected, resp.StatusCode) {
+			err := json.NewDecoder(resp.Body).Decode(out)
+			closeBody(resp.Body)
+			if err != nil {
+				return fmt.Errorf("client: decode %s %s response: %w", method, path, err)
+			}
+			return nil
+		}
+
+		apiErr := decodeError(resp)
+		closeBody(resp.Body)
+		if !retryable(resp.StatusCode) || attempt >= c.retry.MaxAttempts {
+			return apiErr
+		}
+		wait := c.backoff(attempt)
+		if after, ok := retryAfter(resp.Header); ok {
+			if after > c.retry.MaxBackoff {
+				// The server asked for a longer pause than we are willing to
+				// wait; let the caller decide.
+				return apiErr
+			}
+			if after > wait {
+				wait = after
+			}
+		}
+		if err := c.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// closeBody drains what is left of a response body, up to maxDrainBody, and
+// closes it, so the connection can be reused by the next attempt.
+func closeBody(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxDrainBody))
+	body.Close()
+}
+
+func (c *Client) send(ctx context.Context, method, target string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json, application/problem+json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if id := c.requestID(ctx); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+	return c.httpClient.Do(req)
+}
+
+// backoff returns the wait after the given failed attempt (1-based).
+func (c *Client) backoff(attempt int) time.Duration {
+	bound := c.retry.MinBackoff
+	for i := 1; i < attempt && bound < c.retry.MaxBackoff; i++ {
+		bound *= 2
+	}
+	if bound > c.retry.MaxBackoff {
+		bound = c.retry.MaxBackoff
+	}
+	if bound <= 0 {
+		return 0
+	}
+	half := bound / 2
+	return half + time.Duration(rand.Int63n(int64(bound-half)+1))
+}
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// retryAfter parses a Retry-After header given in seconds or as a date.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		d := time.Until(at)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func contains(statuses []int, status int) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStatus reports whether err is an *Error with the given HTTP status.
+func IsStatus(err error, status int) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == status
+}
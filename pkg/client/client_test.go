@@ -0,0 +1,273 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient returns a client for srv whose waits are recorded instead
+// of slept.
+func newTestClient(t *testing.T, srv *httptest.Server, opts ...Option) (*Client, *[]time.Duration) {
+	t.Helper()
+	c, err := New(srv.URL, opts...)
+	require.NoError(t, err)
+	var waits []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	return c, &waits
+}
+
+func TestNew_RejectsInvalidBaseURL(t *testing.T) {
+	for _, base := range []string{"", "toy-service:8080", "ftp://toy", "/echo"} {
+		_, err := New(base)
+		require.Error(t, err, base)
+	}
+}
+
+func TestClient_Echo(t *testing.T) {
+	t.Log("Test that requests carry the body, headers and base path prefix")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/toy/v1/echo", r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "application/json, application/problem+json", r.Header.Get("Accept"))
+		require.Equal(t, "toy-web/1.0", r.Header.Get("User-Agent"))
+		require.Equal(t, "req-42", r.Header.Get(RequestIDHeader))
+		body, _ := io.ReadAll(r.Body)
+		require.JSONEq(t, `{"message":"hi"}`, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"message":"hi [modified]","version":"v1","commit":"abc","env":"dev"}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL+"/toy/", WithUserAgent("toy-web/1.0"))
+	require.NoError(t, err)
+	resp, err := c.Echo(ContextWithRequestID(context.Background(), "req-42"), "hi")
+	require.NoError(t, err)
+	require.Equal(t, &EchoResponse{Message: "hi [modified]", Version: "v1", Commit: "abc", Env: "dev"}, resp)
+}
+
+func TestClient_RequestIDFunc(t *testing.T) {
+	type key struct{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "from-middleware", r.Header.Get(RequestIDHeader))
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	c, _ := newTestClient(t, srv, WithRequestIDFunc(func(ctx context.Context) string {
+		id, _ := ctx.Value(key{}).(string)
+		return id
+	}))
+	_, err := c.Healthz(context.WithValue(context.Background(), key{}, "from-middleware"))
+	require.NoError(t, err)
+}
+
+func TestClient_Errors(t *testing.T) {
+	t.Log("Test that both error shapes decode into *Error")
+
+	for name, tc := range map[string]struct {
+		contentType, body string
+		want              Problem
+	}{
+		"problem": {
+			"application/problem+json",
+			`{"type":"urn:toy-service:problem:validation","title":"Invalid request","status":400,"detail":"Invalid input","instance":"/echo","requestId":"abc","errors":[{"field":"message","detail":"must not be empty"}]}`,
+			Problem{Type: "urn:toy-service:problem:validation", Title: "Invalid request", Status: 400, Detail: "Invalid input", Instance: "/echo", RequestID: "abc", Errors: []FieldError{{Field: "message", Detail: "must not be empty"}}},
+		},
+		"legacy": {
+			"application/json",
+			`{"error":"Invalid input","requestId":"abc"}`,
+			Problem{Title: "Bad Request", Status: 400, Detail: "Invalid input", RequestID: "abc"},
+		},
+		"text": {
+			"text/plain",
+			"bad request\n",
+			Problem{Title: "Bad Request", Status: 400, RequestID: "from-header"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.Header().Set(RequestIDHeader, "from-header")
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			c, waits := newTestClient(t, srv)
+			_, err := c.Echo(context.Background(), "")
+
+			var apiErr *Error
+			require.True(t, errors.As(err, &apiErr))
+			require.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+			require.Equal(t, tc.want, apiErr.Problem)
+			require.True(t, IsStatus(err, http.StatusBadRequest))
+			require.Empty(t, *waits, "4xx responses are not retried")
+		})
+	}
+}
+
+func TestError_Error(t *testing.T) {
+	err := &Error{StatusCode: 413, Problem: Problem{Title: "Request Entity Too Large", Detail: "Payload too large (max 1MiB)", RequestID: "abc"}}
+	require.Equal(t, "toy-service: 413 Payload too large (max 1MiB) (request ID abc)", err.Error())
+	require.Equal(t, "toy-service: 502 Bad Gateway", (&Error{StatusCode: 502}).Error())
+}
+
+func TestClient_Retries(t *testing.T) {
+	t.Log("Test that 5xx and 429 responses are retried with growing backoff and Retry-After honoured")
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			body, _ := io.ReadAll(r.Body)
+			require.JSONEq(t, `{"message":"hi"}`, string(body), "the body is resent on every attempt")
+			_, _ = w.Write([]byte(`{"message":"hi [modified]","version":"v1","commit":"abc","env":"dev"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c, waits := newTestClient(t, srv, WithRetryPolicy(RetryPolicy{MaxAttempts: 4, MinBackoff: 100 * time.Millisecond, MaxBackoff: 2 * time.Second}))
+	resp, err := c.Echo(context.Background(), "hi")
+	require.NoError(t, err)
+	require.Equal(t, "hi [modified]", resp.Message)
+	require.Equal(t, int32(3), calls.Load())
+	require.Len(t, *waits, 2)
+	require.GreaterOrEqual(t, (*waits)[0], 50*time.Millisecond)
+	require.LessOrEqual(t, (*waits)[0], 100*time.Millisecond)
+	require.Equal(t, time.Second, (*waits)[1])
+}
+
+func TestClient_RetriesReuseConnection(t *testing.T) {
+	t.Log("Test that an error body longer than the part decoded is drained so the retry reuses the connection")
+
+	var calls, conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write(make([]byte, maxErrorBody+1024))
+			return
+		}
+		_, _ = w.Write([]byte(`{"version":"v1","commit":"abc"}`))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	c, _ := newTestClient(t, srv)
+	_, err := c.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(2), calls.Load())
+	require.Equal(t, int32(1), conns.Load())
+}
+
+// trackedBody records whether it was closed.
+type trackedBody struct {
+	*bytes.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestCloseBody(t *testing.T) {
+	t.Log("Test that closeBody drains up to maxDrainBody before closing")
+
+	body := &trackedBody{Reader: bytes.NewReader(make([]byte, 1024))}
+	closeBody(body)
+	require.True(t, body.closed)
+	require.Zero(t, body.Len())
+
+	body = &trackedBody{Reader: bytes.NewReader(make([]byte, maxDrainBody+1))}
+	closeBody(body)
+	require.True(t, body.closed)
+	require.Equal(t, 1, body.Len())
+}
+
+func TestClient_RetriesExhausted(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c, waits := newTestClient(t, srv)
+	_, err := c.Version(context.Background())
+	require.True(t, IsStatus(err, http.StatusInternalServerError))
+	require.Equal(t, int32(DefaultRetryPolicy.MaxAttempts), calls.Load())
+	require.Len(t, *waits, DefaultRetryPolicy.MaxAttempts-1)
+
+	t.Log("Test that a Retry-After beyond MaxBackoff ends the retries")
+	calls.Store(0)
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	_, err = c.Info(context.Background())
+	require.True(t, IsStatus(err, http.StatusServiceUnavailable))
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_ProbeFailureIsAResult(t *testing.T) {
+	t.Log("Test that a failing probe returns its report without retrying")
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, verbose := r.URL.Query()["verbose"]
+		require.True(t, verbose)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"status":"fail","checks":[{"name":"secrets","status":"fail","error":"missing","durationMs":0.1}]}`))
+	}))
+	defer srv.Close()
+
+	c, _ := newTestClient(t, srv)
+	report, err := c.Readyz(context.Background(), true)
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Equal(t, []HealthCheckResult{{Name: "secrets", Status: "fail", Error: "missing", DurationMs: 0.1}}, report.Checks)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_ContextCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Info(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,303 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/api"
+	"github.com/paulcapestany/toy-service/internal/apidocs"
+	"github.com/paulcapestany/toy-service/internal/apiversion"
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/contract"
+	"github.com/paulcapestany/toy-service/internal/handlers"
+	"github.com/paulcapestany/toy-service/internal/health"
+	"github.com/paulcapestany/toy-service/internal/problem"
+	"github.com/paulcapestany/toy-service/internal/requestid"
+	"github.com/paulcapestany/toy-service/spec"
+)
+
+// operations maps every operation in spec/openapi.yaml to the Client method
+// calling it and the schema of its successful response. A new operation in
+// the spec fails TestSpecDrift until it is added here (and to the client) or
+// to skipped.
+var operations = map[string]struct {
+	method string
+	schema string
+}{
+	"POST /echo":    {"Echo", "EchoResponse"},
+	"GET /info":     {"Info", "InfoResponse"},
+	"GET /version":  {"Version", "VersionResponse"},
+	"GET /healthz":  {"Healthz", "HealthResponse"},
+	"GET /livez":    {"Livez", "HealthReport"},
+	"GET /readyz":   {"Readyz", "HealthReport"},
+	"GET /startupz": {"Startupz", "HealthReport"},
+}
+
+// skipped lists operations the client deliberately does not wrap.
+var skipped = map[string]string{
+	"GET /openapi.yaml": "the spec itself; fetch it with any HTTP client",
+	"GET /openapi.json": "the spec itself; fetch it with any HTTP client",
+}
+
+// schemas maps each component schema to the Go type mirroring it.
+var schemas = map[string]reflect.Type{
+	"EchoRequest":       reflect.TypeOf(EchoRequest{}),
+	"EchoResponse":      reflect.TypeOf(EchoResponse{}),
+	"InfoResponse":      reflect.TypeOf(InfoResponse{}),
+	"VersionResponse":   reflect.TypeOf(VersionResponse{}),
+	"HealthResponse":    reflect.TypeOf(HealthResponse{}),
+	"HealthReport":      reflect.TypeOf(HealthReport{}),
+	"HealthCheckResult": reflect.TypeOf(HealthCheckResult{}),
+	"Problem":           reflect.TypeOf(Problem{}),
+	"FieldError":        reflect.TypeOf(FieldError{}),
+	"ErrorResponse":     reflect.TypeOf(ErrorResponse{}),
+}
+
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData(spec.OpenAPI)
+	require.NoError(t, err)
+	return doc
+}
+
+func TestSpecDrift(t *testing.T) {
+	doc := loadSpec(t)
+	client := reflect.TypeOf(&Client{})
+
+	t.Log("Test that every operation in the spec is covered by a client method")
+	seen := map[string]bool{}
+	for path, item := range doc.Paths.Map() {
+		for method, op := range item.Operations() {
+			key := method + " " + path
+			seen[key] = true
+			if _, ok := skipped[key]; ok {
+				continue
+			}
+			want, ok := operations[key]
+			if !assertf(t, ok, "%s is in the spec but not in the client", key) {
+				continue
+			}
+			_, ok = client.MethodByName(want.method)
+			assertf(t, ok, "%s: Client.%s does not exist", key, want.method)
+
+			ok200 := op.Responses.Status(http.StatusOK)
+			if assertf(t, ok200 != nil, "%s: no 200 response", key) {
+				media := ok200.Value.Content.Get("application/json")
+				if assertf(t, media != nil && media.Schema != nil, "%s: 200 response has no JSON schema", key) {
+					assertf(t, media.Schema.Ref == "#/components/schemas/"+want.schema,
+						"%s: 200 response is %q, the client decodes %s", key, media.Schema.Ref, want.schema)
+				}
+			}
+
+			// Every body the operation can return must have a Go type.
+			for status, resp := range op.Responses.Map() {
+				for contentType, media := range resp.Value.Content {
+					if media.Schema == nil {
+						continue
+					}
+					name := strings.TrimPrefix(media.Schema.Ref, "#/components/schemas/")
+					_, ok := schemas[name]
+					assertf(t, ok, "%s: %s %s response uses schema %q with no Go type", key, status, contentType, name)
+				}
+			}
+		}
+	}
+	for key := range operations {
+		assertf(t, seen[key], "the client calls %s, which is not in the spec", key)
+	}
+
+	t.Log("Test that every Go type matches its schema property for property")
+	for name, typ := range schemas {
+		ref := doc.Components.Schemas[name]
+		if !assertf(t, ref != nil, "schema %s is not in the spec", name) {
+			continue
+		}
+		compareSchema(t, name, ref.Value, typ)
+	}
+}
+
+// compareSchema fails t for every property present on only one side, with
+// a mismatched type, or required by the spec but omitted when empty in Go.
+func compareSchema(t *testing.T, where string, schema *openapi3.Schema, typ reflect.Type) {
+	t.Helper()
+	fields := map[string]reflect.StructField{}
+	omitempty := map[string]bool{}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = f
+		omitempty[name] = strings.Contains(opts, "omitempty")
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := schema.Properties[name].Value
+		f, ok := fields[name]
+		if !assertf(t, ok, "%s.%s is in the spec but not in %s", where, name, typ.Name()) {
+			continue
+		}
+		delete(fields, name)
+		compareType(t, where+"."+name, prop, f.Type)
+	}
+	for name := range fields {
+		assertf(t, false, "%s.%s is in %s but not in the spec", where, name, typ.Name())
+	}
+	for _, name := range schema.Required {
+		assertf(t, !omitempty[name], "%s.%s is required by the spec but omitempty in %s", where, name, typ.Name())
+	}
+}
+
+func compareType(t *testing.T, where string, schema *openapi3.Schema, typ reflect.Type) {
+	t.Helper()
+	var want string
+	switch typ.Kind() {
+	case reflect.String:
+		want = openapi3.TypeString
+	case reflect.Bool:
+		want = openapi3.TypeBoolean
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		want = openapi3.TypeInteger
+	case reflect.Float32, reflect.Float64:
+		want = openapi3.TypeNumber
+	case reflect.Slice:
+		want = openapi3.TypeArray
+	case reflect.Struct:
+		want = openapi3.TypeObject
+	default:
+		assertf(t, false, "%s: unsupported Go kind %s", where, typ.Kind())
+		return
+	}
+	if !assertf(t, schema.Type.Is(want), "%s is %v in the spec but %s in Go", where, schema.Type.Slice(), typ) {
+		return
+	}
+	switch typ.Kind() {
+	case reflect.Slice:
+		compareType(t, where+"[]", schema.Items.Value, typ.Elem())
+	case reflect.Struct:
+		compareSchema(t, where, schema, typ)
+	}
+}
+
+func assertf(t *testing.T, ok bool, format string, args ...any) bool {
+	t.Helper()
+	if !ok {
+		t.Errorf(format, args...)
+	}
+	return ok
+}
+
+type violations struct {
+	mu   sync.Mutex
+	seen []string
+}
+
+func (v *violations) ObserveContractViolation(direction, route string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.seen = append(v.seen, direction+" "+route)
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Log("Test that every client call against the real handlers satisfies the contract both ways")
+
+	store := config.NewStore(config.Snapshot{
+		Name:      "toy-service",
+		Env:       "test",
+		Version:   "v0.0.0-test",
+		GitCommit: "abc1234",
+		SecretDir: config.DefaultSecretDir,
+		Secrets:   map[string]string{"FAKE_SECRET": "shh"},
+	})
+	probes := health.NewRegistry()
+	require.NoError(t, probes.Register(health.Check{
+		Name:   "always",
+		Probes: health.Liveness | health.Readiness | health.Startup,
+		Run:    func(context.Context) error { return nil },
+	}))
+	require.NoError(t, probes.Register(health.Check{
+		Name:   "dependency",
+		Probes: health.Readiness,
+		Run:    func(context.Context) error { return errors.New("unavailable") },
+	}))
+
+	observed := &violations{}
+	validate, err := contract.New(spec.OpenAPI, contract.Options{Mode: contract.Shadow, Observer: observed, Prefixes: []string{"/v1"}})
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(requestid.Middleware)
+	r.Use(validate)
+	docs, err := apidocs.New(spec.OpenAPI, store, apidocs.Options{BasePath: "/v1"})
+	require.NoError(t, err)
+	routes := chi.NewRouter()
+	api.RegisterHandlers(routes, handlers.NewServer(handlers.Deps{Config: store, Probes: probes, Docs: docs}))
+	require.NoError(t, apiversion.Mount(r, routes, nil, apiversion.Version{Name: "v1", Prefix: "/v1"}))
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	c, err := New(srv.URL, WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	require.NoError(t, err)
+	ctx := ContextWithRequestID(context.Background(), "round-trip")
+
+	echo, err := c.Echo(ctx, "hi")
+	require.NoError(t, err)
+	require.Equal(t, "hi [modified]", echo.Message)
+
+	info, err := c.Info(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "toy-service", info.Name)
+	require.True(t, info.FakeSecretPresent)
+	require.Equal(t, 3, info.FakeSecretLength)
+
+	version, err := c.Version(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "v0.0.0-test", version.Version)
+
+	healthz, err := c.Healthz(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "ok", healthz.Status)
+
+	live, err := c.Livez(ctx, true)
+	require.NoError(t, err)
+	require.True(t, live.OK())
+	require.Len(t, live.Checks, 1)
+
+	ready, err := c.Readyz(ctx, true)
+	require.NoError(t, err)
+	require.False(t, ready.OK())
+	require.Len(t, ready.Checks, 2)
+
+	startup, err := c.Startupz(ctx, false)
+	require.NoError(t, err)
+	require.True(t, startup.OK())
+	require.Empty(t, startup.Checks)
+
+	_, err = c.Echo(ctx, "")
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	require.Equal(t, problem.TypeValidation, apiErr.Type)
+	require.Equal(t, "round-trip", apiErr.RequestID)
+	require.Equal(t, []FieldError{{Field: "message", Detail: apiErr.Errors[0].Detail}}, apiErr.Errors)
+
+	// The rejected empty message is the only expected violation.
+	require.Equal(t, []string{"request /echo"}, observed.seen)
+}
@@ -0,0 +1,71 @@
+// errors.go
+//
+// Turns non-success responses into *Error, whichever of the two error
+// shapes (problem details or the legacy {"error": "..."}) the server sent.
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// maxErrorBody caps how much of an error response is read.
+const maxErrorBody = 64 << 10
+
+// Error is returned for every response with an unexpected status. The
+// embedded Problem carries the server's explanation; RequestID quotes the
+// X-Request-Id to include in bug reports.
+type Error struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	Problem
+}
+
+func (e *Error) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	s := fmt.Sprintf("toy-service: %d %s", e.StatusCode, msg)
+	if e.RequestID != "" {
+		s += " (request ID " + e.RequestID + ")"
+	}
+	return s
+}
+
+// decodeError reads resp's body into an *Error. Bodies that are not JSON
+// still yield an Error carrying the status.
+func decodeError(resp *http.Response) *Error {
+	e := &Error{StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/problem+json":
+		_ = json.Unmarshal(body, &e.Problem)
+	case "application/json":
+		var legacy ErrorResponse
+		if json.Unmarshal(body, &legacy) == nil {
+			e.Detail = legacy.Error
+			e.RequestID = legacy.RequestID
+		}
+	}
+
+	if e.Status == 0 {
+		e.Status = resp.StatusCode
+	}
+	if e.Title == "" {
+		e.Title = http.StatusText(resp.StatusCode)
+	}
+	if e.RequestID == "" {
+		e.RequestID = resp.Header.Get(RequestIDHeader)
+	}
+	return e
+}
@@ -0,0 +1,91 @@
+// types.go
+//
+// Request and response bodies of the toy-service API. Each type mirrors the
+// schema of the same name in spec/openapi.yaml; drift_test.go fails when a
+// property is added, removed or changes type on either side.
+
+package client
+
+// EchoRequest is the body of POST /echo.
+type EchoRequest struct {
+	Message string `json:"message"`
+}
+
+// EchoResponse is returned by POST /echo.
+type EchoResponse struct {
+	Message string `json:"message"`
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Env     string `json:"env"`
+}
+
+// InfoResponse is returned by GET /info.
+type InfoResponse struct {
+	Name              string `json:"name"`
+	Version           string `json:"version"`
+	Env               string `json:"env"`
+	LogVerbosity      string `json:"logVerbosity"`
+	FakeSecretPresent bool   `json:"fakeSecretPresent"`
+	FakeSecretLength  int    `json:"fakeSecretLength,omitempty"`
+	Commit            string `json:"commit"`
+	ConfigGeneration  uint64 `json:"configGeneration"`
+	UptimeSeconds     int64  `json:"uptimeSeconds"`
+}
+
+// VersionResponse is returned by GET /version.
+type VersionResponse struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+}
+
+// HealthResponse is returned by the legacy GET /healthz probe.
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// HealthReport is returned by GET /livez, /readyz and /startupz.
+type HealthReport struct {
+	// Status is "ok" or "fail".
+	Status string `json:"status"`
+	// Checks is only filled in for verbose probes.
+	Checks []HealthCheckResult `json:"checks,omitempty"`
+}
+
+// OK reports whether the probe passed.
+func (r *HealthReport) OK() bool {
+	return r.Status == "ok"
+}
+
+// HealthCheckResult is one check within a verbose HealthReport.
+type HealthCheckResult struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"durationMs"`
+	Cached     bool    `json:"cached,omitempty"`
+}
+
+// Problem is an RFC 7807 error body (application/problem+json).
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is one invalid field listed by a validation Problem.
+type FieldError struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// ErrorResponse is the legacy error body, still understood for servers
+// that predate problem details.
+type ErrorResponse struct {
+	Error     string `json:"error"`
+	RequestID string `json:"requestId,omitempty"`
+}
@@ -0,0 +1,71 @@
+// admin.go
+//
+// The admin listener: a second HTTP server, bound to localhost by default,
+// carrying the operational routes (secret reloads, internal diagnostics,
+// log level, metrics and pprof) so that public ingress can never reach them.
+
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/paulcapestany/toy-service/internal/accesslog"
+	"github.com/paulcapestany/toy-service/internal/auth"
+	"github.com/paulcapestany/toy-service/internal/corspolicy"
+	"github.com/paulcapestany/toy-service/internal/handlers"
+	"github.com/paulcapestany/toy-service/internal/metrics"
+	"github.com/paulcapestany/toy-service/internal/problem"
+	"github.com/paulcapestany/toy-service/internal/requestid"
+	"github.com/paulcapestany/toy-service/internal/tracing"
+)
+
+// newAdminRouter registers every operational route. Each keeps its
+// authorization policy as well, so exposing the admin listener beyond
+// localhost does not open the routes up.
+func newAdminRouter(h *handlers.Server, m *metrics.Metrics, tracer *tracing.Provider, policies auth.Policies, accessLogOpts accesslog.Options) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(requestid.Middleware)
+	// Trace and count operational requests like public ones, so reloads
+	// show up in spans and in http_requests_*.
+	r.Use(tracer.Middleware)
+	r.Use(accesslog.New(accessLogOpts))
+	r.Use(m.Middleware)
+	// Operational routes are never reachable from a browser on another origin.
+	r.Use(corspolicy.Deny)
+	r.NotFound(problem.NotFound)
+	r.MethodNotAllowed(problem.MethodNotAllowed)
+
+	// Internal (non-public) endpoints, restricted by INTERNAL_* credentials/networks
+	r.Route("/internal", func(r chi.Router) {
+		r.Use(auth.Require(policies.Internal))
+		// Verify secret presence without exposing values
+		r.Get("/config", h.InternalConfig)
+	})
+	// Go runtime profiles under /debug/pprof/, guarded like /internal/*
+	r.With(auth.Require(policies.Internal)).Mount("/debug", middleware.Profiler())
+	// Reload webhook for in-place secret reloads (bearer token or HMAC signature)
+	r.With(auth.Require(policies.Reload)).Post("/-/reload", h.Reload)
+	// Runtime log level inspection/changes (requires ADMIN_TOKEN bearer auth)
+	r.With(auth.Require(policies.Admin)).Get("/-/log-level", h.LogLevel)
+	r.With(auth.Require(policies.Admin)).Put("/-/log-level", h.LogLevel)
+	// Prometheus scrape endpoint (text exposition format)
+	r.Get("/metrics", h.Metrics)
+
+	return r
+}
+
+// newAdminServer returns the admin HTTP server for h. The write timeout is
+// long enough for the default 30s CPU profile.
+func newAdminServer(h http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+}
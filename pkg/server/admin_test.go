@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/accesslog"
+	"github.com/paulcapestany/toy-service/internal/auth"
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/handlers"
+	"github.com/paulcapestany/toy-service/internal/metrics"
+	"github.com/paulcapestany/toy-service/internal/netutil"
+	"github.com/paulcapestany/toy-service/internal/problem"
+	"github.com/paulcapestany/toy-service/internal/secrets"
+	"github.com/paulcapestany/toy-service/internal/tracing"
+)
+
+// noopTracer returns a provider that records no spans.
+func noopTracer(t *testing.T) *tracing.Provider {
+	p, err := tracing.Setup(context.Background(), tracing.Config{Exporter: tracing.ExporterNone})
+	require.NoError(t, err)
+	return p
+}
+
+func TestAdminRouter(t *testing.T) {
+	t.Log("Test that operational routes are served by the admin router")
+
+	store := config.NewStore(config.Snapshot{SecretDir: t.TempDir()})
+	policies := auth.Policies{
+		Reload:   auth.Policy{Networks: netutil.Loopback()},
+		Internal: auth.Policy{Networks: netutil.Loopback()},
+	}
+	m := metrics.New()
+	h := handlers.NewServer(handlers.Deps{
+		Config:         store,
+		Reloader:       secrets.NewReloader(store, secrets.DefaultSchema),
+		MetricsHandler: m.Handler(),
+	})
+	r := newAdminRouter(h, m, noopTracer(t), policies, accesslog.Options{})
+
+	for _, tc := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/metrics", http.StatusOK},
+		{http.MethodGet, "/internal/config", http.StatusOK},
+		{http.MethodGet, "/debug/pprof/", http.StatusOK},
+		{http.MethodGet, "/-/log-level", http.StatusForbidden},
+		{http.MethodGet, "/echo", http.StatusNotFound},
+		{http.MethodDelete, "/metrics", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/internal/missing", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.RemoteAddr = "127.0.0.1:40000"
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		require.Equal(t, tc.want, rec.Code, tc.path)
+		if tc.want >= http.StatusBadRequest {
+			require.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"), tc.path)
+		}
+	}
+
+	t.Log("Test that route policies still apply to non-loopback callers")
+	req := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+	req.RemoteAddr = "203.0.113.7:40000"
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestAdminRouter_RejectsCORS(t *testing.T) {
+	t.Log("Test that operational routes reject CORS preflights and send no CORS headers")
+
+	store := config.NewStore(config.Snapshot{SecretDir: t.TempDir()})
+	policies := auth.Policies{
+		Reload:   auth.Policy{Networks: netutil.Loopback()},
+		Internal: auth.Policy{Networks: netutil.Loopback()},
+	}
+	m := metrics.New()
+	h := handlers.NewServer(handlers.Deps{
+		Config:         store,
+		Reloader:       secrets.NewReloader(store, secrets.DefaultSchema),
+		MetricsHandler: m.Handler(),
+	})
+	r := newAdminRouter(h, m, noopTracer(t), policies, accesslog.Options{})
+
+	for _, path := range []string{"/-/reload", "/internal/config"} {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.RemoteAddr = "127.0.0.1:40000"
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusForbidden, rec.Code, path)
+		require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"), path)
+	}
+}
+
+func TestAdminRouter_RecordsMetrics(t *testing.T) {
+	t.Log("Test that operational requests are counted in the HTTP metrics")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, config.FakeSecretName), []byte("secret"), 0o600))
+	store := config.NewStore(config.Snapshot{SecretDir: dir})
+	policies := auth.Policies{Reload: auth.Policy{Networks: netutil.Loopback()}}
+	m := metrics.New()
+	h := handlers.NewServer(handlers.Deps{
+		Config:         store,
+		Reloader:       secrets.NewReloader(store, nil),
+		MetricsHandler: m.Handler(),
+	})
+	r := newAdminRouter(h, m, noopTracer(t), policies, accesslog.Options{})
+
+	req := httptest.NewRequest(http.MethodPost, "/-/reload", nil)
+	req.RemoteAddr = "127.0.0.1:40000"
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `toy_service_http_requests_total{method="POST",route="/-/reload",status="200"} 1`)
+}
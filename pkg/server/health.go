@@ -0,0 +1,92 @@
+// health.go
+//
+// Registers the service's built-in health checks.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/health"
+	"github.com/paulcapestany/toy-service/internal/secrets"
+)
+
+// registerHealthChecks adds the built-in checks:
+//
+//   - config (all probes): a configuration snapshot has been published.
+//   - secrets (readiness): every required secret in the schema is set.
+//   - admin-listener (readiness): the admin listener accepts connections.
+//
+// adminAddr returns the admin listener's address when the check runs, so a
+// port chosen by the system at Start is dialed rather than the configured 0.
+func registerHealthChecks(reg *health.Registry, store config.Provider, schema secrets.Schema, adminAddr func() string) error {
+	checks := []health.Check{
+		{
+			Name:   "config",
+			Probes: health.Liveness | health.Readiness | health.Startup,
+			Run: func(context.Context) error {
+				if snap := store.Load(); snap == nil || snap.Generation == 0 {
+					return errors.New("no configuration snapshot published")
+				}
+				return nil
+			},
+		},
+		{
+			Name:   "secrets",
+			Probes: health.Readiness,
+			Run: func(context.Context) error {
+				snap := store.Load()
+				var missing []string
+				for _, spec := range schema {
+					if spec.Required && snap.Secret(spec.Name) == "" {
+						missing = append(missing, spec.Name)
+					}
+				}
+				if len(missing) > 0 {
+					return fmt.Errorf("missing required secrets: %s", strings.Join(missing, ", "))
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "admin-listener",
+			Probes:   health.Readiness,
+			Timeout:  time.Second,
+			CacheTTL: 5 * time.Second,
+			Run: func(ctx context.Context) error {
+				var d net.Dialer
+				conn, err := d.DialContext(ctx, "tcp", dialAddr(adminAddr()))
+				if err != nil {
+					return err
+				}
+				return conn.Close()
+			},
+		},
+	}
+
+	for _, c := range checks {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dialAddr maps a listen address on the unspecified host to loopback so it
+// can be dialed.
+func dialAddr(listenAddr string) string {
+	host, port, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return listenAddr
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port)
+}
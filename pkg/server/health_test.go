@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/health"
+	"github.com/paulcapestany/toy-service/internal/secrets"
+)
+
+func TestHealthChecks(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	store := config.NewStore(config.Snapshot{})
+	schema := secrets.Schema{{Name: "FAKE_SECRET", Required: true}, {Name: "API_TOKEN"}}
+	reg := health.NewRegistry()
+	require.NoError(t, registerHealthChecks(reg, store, schema, ln.Addr().String))
+
+	t.Log("Test that readiness fails while a required secret is missing")
+	report := reg.Run(context.Background(), health.Readiness)
+	require.Equal(t, health.StatusFail, report.Status)
+	require.Equal(t, "secrets", report.Checks[2].Name)
+	require.Equal(t, "missing required secrets: FAKE_SECRET", report.Checks[2].Error)
+	require.Equal(t, health.StatusOK, reg.Run(context.Background(), health.Liveness).Status)
+
+	_, err = store.Update(func(s *config.Snapshot) error {
+		s.Secrets = map[string]string{"FAKE_SECRET": "x"}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, health.StatusOK, reg.Run(context.Background(), health.Readiness).Status)
+}
+
+func TestHealthChecks_DefaultSchema(t *testing.T) {
+	t.Log("Test that readiness passes by default without FAKE_SECRET")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	reg := health.NewRegistry()
+	require.NoError(t, registerHealthChecks(reg, config.NewStore(config.Snapshot{}), secrets.DefaultSchema, ln.Addr().String))
+	require.Equal(t, health.StatusOK, reg.Run(context.Background(), health.Readiness).Status)
+}
+
+func TestDialAddr(t *testing.T) {
+	require.Equal(t, "127.0.0.1:8081", dialAddr("0.0.0.0:8081"))
+	require.Equal(t, "127.0.0.1:8081", dialAddr(":8081"))
+	require.Equal(t, "[::1]:8081", dialAddr("[::1]:8081"))
+	require.Equal(t, "127.0.0.1:8081", dialAddr("[::]:8081"))
+}
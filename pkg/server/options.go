@@ -0,0 +1,114 @@
+// options.go
+//
+// Functional options for New. Anything not set by an option comes from the
+// service configuration, which by default is read from the environment like
+// the toy-service binary does.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/health"
+)
+
+// Config is the complete static configuration of the service; see
+// `toy-service --help` for every setting.
+type Config = config.Config
+
+// DefaultConfig returns the configuration used when nothing is overridden,
+// as a starting point for WithConfig.
+func DefaultConfig() Config {
+	return config.Default()
+}
+
+// HealthCheck is a named check run by the probes it is registered for.
+type HealthCheck = health.Check
+
+// Probe identifies a Kubernetes probe endpoint; checks may be combined with |.
+type Probe = health.Probe
+
+// The probes a HealthCheck can run under.
+const (
+	Liveness  = health.Liveness
+	Readiness = health.Readiness
+	Startup   = health.Startup
+)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithConfig uses cfg instead of loading the configuration. It is validated
+// by New with the same checks the binary applies to its configuration.
+func WithConfig(cfg Config) Option {
+	return func(s *Server) {
+		s.load = func() (Config, error) {
+			return cfg, config.Validate(cfg, Validators...)
+		}
+	}
+}
+
+// WithConfigSource loads the configuration the way the binary does: from
+// the config file, environment and flags in args (e.g. os.Args[1:]).
+// lookupEnv is usually os.LookupEnv. The default is the environment alone:
+// WithConfigSource(nil, os.LookupEnv).
+func WithConfigSource(args []string, lookupEnv func(string) (string, bool)) Option {
+	return func(s *Server) {
+		s.load = func() (Config, error) {
+			cfg, _, err := config.Load(args, lookupEnv, Validators...)
+			return cfg, err
+		}
+	}
+}
+
+// WithAddr sets the public TCP listen address (default ":<server.port>"),
+// e.g. "127.0.0.1:0" to let the system choose a port. A configured Unix
+// socket, or a socket passed by systemd with WithSocketActivation, still
+// takes precedence.
+func WithAddr(addr string) Option {
+	return func(s *Server) { s.addr = addr }
+}
+
+// WithSocketActivation serves the public listener on a socket passed via
+// LISTEN_FDS (the systemd sd_listen_fds protocol), when there is one, and
+// clears the LISTEN_* variables. Only the process the socket was passed to
+// should use it; embedded servers ignore LISTEN_FDS by default.
+func WithSocketActivation() Option {
+	return func(s *Server) { s.activation = true }
+}
+
+// WithAdminAddr sets the admin listen address (default
+// "<server.adminHost>:<server.adminPort>").
+func WithAdminAddr(addr string) Option {
+	return func(s *Server) { s.adminAddr = addr }
+}
+
+// WithLogger sets the logger for the server's own logs and the base of
+// every request-scoped logger (default: the global zerolog logger).
+func WithLogger(logger zerolog.Logger) Option {
+	return func(s *Server) { s.logger = logger }
+}
+
+// WithRoutes registers extra routes on the public router. They are served
+// as registered, not under /v1, behind the same middlewares as the API. It
+// may be given more than once.
+func WithRoutes(register func(r chi.Router)) Option {
+	return func(s *Server) { s.routes = append(s.routes, register) }
+}
+
+// WithMiddleware adds middlewares to the public router. They run after the
+// built-in ones (request ID, tracing, access log, metrics, body limit, CORS
+// and contract validation), in the order given.
+func WithMiddleware(middlewares ...func(http.Handler) http.Handler) Option {
+	return func(s *Server) { s.middlewares = append(s.middlewares, middlewares...) }
+}
+
+// WithHealthCheck registers checks alongside the built-in ones, served by
+// /livez, /readyz and /startupz according to their Probes.
+func WithHealthCheck(checks ...HealthCheck) Option {
+	return func(s *Server) { s.checks = append(s.checks, checks...) }
+}
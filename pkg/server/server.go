@@ -0,0 +1,469 @@
+// server.go
+//
+// A toy-service server that can be embedded in another binary or started
+// in-process by tests: the public listener serving the API (see
+// spec/openapi.yaml) and the admin listener serving the operational routes
+// (see admin.go), built from the service configuration and the options
+// given to New. cmd/server is a thin wrapper around it.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/paulcapestany/toy-service/internal/accesslog"
+	"github.com/paulcapestany/toy-service/internal/api"
+	"github.com/paulcapestany/toy-service/internal/apidocs"
+	"github.com/paulcapestany/toy-service/internal/apiversion"
+	"github.com/paulcapestany/toy-service/internal/auth"
+	"github.com/paulcapestany/toy-service/internal/bodylimit"
+	"github.com/paulcapestany/toy-service/internal/config"
+	"github.com/paulcapestany/toy-service/internal/contract"
+	"github.com/paulcapestany/toy-service/internal/corspolicy"
+	"github.com/paulcapestany/toy-service/internal/drain"
+	"github.com/paulcapestany/toy-service/internal/handlers"
+	"github.com/paulcapestany/toy-service/internal/health"
+	"github.com/paulcapestany/toy-service/internal/listener"
+	"github.com/paulcapestany/toy-service/internal/metrics"
+	"github.com/paulcapestany/toy-service/internal/problem"
+	"github.com/paulcapestany/toy-service/internal/requestid"
+	"github.com/paulcapestany/toy-service/internal/secrets"
+	"github.com/paulcapestany/toy-service/internal/tlsconfig"
+	"github.com/paulcapestany/toy-service/internal/tracing"
+	"github.com/paulcapestany/toy-service/spec"
+)
+
+// Validators check the settings owned by the server's components on top of
+// config's own checks. Pass them to config.Load when loading the
+// configuration outside WithConfigSource.
+var Validators = []config.Validator{secrets.SchemaValidator, corspolicy.RoutesValidator}
+
+// flushTimeout bounds flushing pending spans at Shutdown.
+const flushTimeout = 5 * time.Second
+
+// Server is a configured toy-service instance. Create it with New, then
+// call Start and, once done, Shutdown.
+type Server struct {
+	// Set by options.
+	load        func() (Config, error)
+	addr        string
+	adminAddr   string
+	activation  bool
+	logger      zerolog.Logger
+	routes      []func(chi.Router)
+	middlewares []func(http.Handler) http.Handler
+	checks      []HealthCheck
+
+	// Built by New.
+	settings   Config
+	store      *config.Store
+	reloader   *secrets.Reloader
+	drainer    *drain.Drainer
+	certs      *tlsconfig.Reloader
+	listenOpts listener.Options
+	watchOpts  secrets.WatchOptions
+	tracer     *tracing.Provider
+	handler    http.Handler
+	public     *http.Server
+	admin      *http.Server
+	errs       chan error
+
+	// Set by Start and Shutdown.
+	mu      sync.Mutex
+	stop    context.CancelFunc
+	stopped bool
+}
+
+// New builds a server from the configuration and opts. It validates the
+// configuration, loads the TLS certificate, if any, and sets up the
+// server's own tracer provider, but opens no listeners until Start.
+func New(opts ...Option) (_ *Server, err error) {
+	s := &Server{logger: log.Logger, errs: make(chan error, 2)}
+	WithConfigSource(nil, os.LookupEnv)(s)
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	settings, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.settings = settings
+	if s.addr == "" {
+		s.addr = settings.Server.Addr()
+	}
+	if s.adminAddr == "" {
+		s.adminAddr = settings.Server.AdminAddr()
+	}
+	cfg := settings.Snapshot()
+
+	accessLogOpts, err := accesslog.OptionsFromConfig(settings.AccessLog)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access log configuration: %w", err)
+	}
+	policies, err := auth.PoliciesFromConfig(settings.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization configuration: %w", err)
+	}
+
+	// Handlers read the current snapshot per request; reloads swap it atomically.
+	s.store = config.NewStore(cfg)
+	schema, err := secrets.ParseSchema(cfg.SecretSchema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret schema: %w", err)
+	}
+	m := metrics.New()
+	s.reloader = secrets.NewReloader(s.store, schema)
+	s.reloader.SetObserver(m)
+	// Watch the mounted secret directory and reload on change (/-/reload
+	// remains available).
+	s.watchOpts = secrets.WatchOptionsFromConfig(settings.Secrets)
+
+	// Serve TLS (and mTLS with a client CA) on the public listener when
+	// certificates are configured, picking up rotated files automatically.
+	tlsOpts, err := tlsconfig.OptionsFromConfig(settings.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	if tlsOpts.Enabled() {
+		if s.certs, err = tlsconfig.NewReloader(tlsOpts); err != nil {
+			return nil, fmt.Errorf("loading TLS certificate: %w", err)
+		}
+		s.certs.LogCertificate(&s.logger)
+	}
+
+	if s.listenOpts, err = listener.OptionsFromConfig(settings.Server, settings.Limits); err != nil {
+		return nil, fmt.Errorf("invalid listener configuration: %w", err)
+	}
+	s.listenOpts.SocketActivation = s.activation
+
+	s.drainer = drain.New(drain.OptionsFromConfig(settings.Shutdown))
+
+	probes := health.NewRegistry()
+	if err := registerHealthChecks(probes, s.store, schema, s.AdminAddr); err != nil {
+		return nil, fmt.Errorf("registering health checks: %w", err)
+	}
+	// Readiness fails as soon as a drain starts.
+	checks := append([]HealthCheck{s.drainer.Check()}, s.checks...)
+	for _, c := range checks {
+		if err := probes.Register(c); err != nil {
+			return nil, fmt.Errorf("registering health checks: %w", err)
+		}
+	}
+
+	// The API is served under /v1 and, for existing clients, at its
+	// unversioned paths (see versions.go). Per-path settings written for an
+	// unversioned path apply under every prefix.
+	versions := apiVersions()
+	prefixes := apiversion.Prefixes(versions...)
+	accessLogOpts.Prefixes = prefixes
+
+	// Spans go to this server's provider, not the global one, so servers
+	// embedded side by side do not replace or shut down each other's.
+	tcfg := tracing.FromConfig(settings.Tracing)
+	tcfg.ServiceName = cfg.Name
+	tcfg.ServiceVersion = cfg.Version
+	tcfg.Environment = cfg.Env
+	if s.tracer, err = tracing.Setup(context.Background(), tcfg); err != nil {
+		return nil, fmt.Errorf("configuring tracing: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = s.tracer.Shutdown(context.Background())
+		}
+	}()
+	s.logger.Info().Str("exporter", tcfg.Exporter).Msg("Tracing configured")
+
+	r := chi.NewRouter()
+
+	// Log with the server's logger from here on.
+	r.Use(s.withLogger)
+	// Count in-flight requests so shutdown can wait for (or report) them.
+	r.Use(s.drainer.Middleware)
+	// Assign/propagate X-Request-Id so every response and log line carries it.
+	r.Use(requestid.Middleware)
+	// Expose the verified mTLS client identity to handlers and logs.
+	r.Use(tlsconfig.Middleware)
+	// Join inbound W3C trace context and record a server span per route.
+	r.Use(s.tracer.Middleware)
+	// One structured access log line per request (sampled for health probes).
+	r.Use(accesslog.New(accessLogOpts))
+	// Record per-route request counts, errors and latency for every request.
+	r.Use(m.Middleware)
+	// Cap request bodies (limits.maxBodyBytes, or limits.bodyLimits per path).
+	limitOpts := bodylimit.OptionsFromConfig(settings.Limits)
+	limitOpts.Prefixes = prefixes
+	r.Use(bodylimit.New(limitOpts))
+
+	// Apply the CORS policy for this environment (any origin unless
+	// configured); operational paths never get CORS headers.
+	corsOpts := corspolicy.OptionsFromConfig(settings.CORS, cfg.Env)
+	corsOpts.Prefixes = prefixes
+	s.logger.Info().
+		Strs("allowedOrigins", corsOpts.Default.AllowedOrigins).
+		Bool("allowCredentials", corsOpts.Default.Credentials()).
+		Int("routeOverrides", len(corsOpts.Routes)).
+		Msg("CORS configured")
+	if cfg.Env != "dev" && corsOpts.Default.AnyOrigin() {
+		s.logger.Warn().Str("env", cfg.Env).Msg("CORS allows any origin; list the front-end origins in cors.allowedOrigins")
+	}
+	r.Use(corspolicy.New(corsOpts))
+	// Optionally validate traffic against the embedded OpenAPI spec
+	// (openapi.validation: enforce rejects bad requests, shadow also checks responses).
+	contractOpts := contract.OptionsFromConfig(settings.OpenAPI)
+	contractOpts.Observer = m
+	contractOpts.Prefixes = prefixes
+	validate, err := contract.New(spec.OpenAPI, contractOpts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+	s.logger.Info().Str("mode", string(contractOpts.Mode)).Msg("OpenAPI validation configured")
+	r.Use(validate)
+	// Middlewares added by the embedding application
+	r.Use(s.middlewares...)
+	// Unknown routes and methods get problem+json like every other error
+	r.NotFound(problem.NotFound)
+	r.MethodNotAllowed(problem.MethodNotAllowed)
+
+	// Serve the spec with the live version and this deployment's server URL.
+	docsOpts := apidocs.OptionsFromConfig(settings.OpenAPI)
+	docsOpts.BasePath = currentPrefix
+	docsOpts.TrustedProxies = accessLogOpts.TrustedProxies
+	docs, err := apidocs.New(spec.OpenAPI, s.store, docsOpts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+
+	// Register routes: every operation in spec/openapi.yaml, implemented by
+	// handlers.Server, under /v1 and at its unversioned path (see
+	// versions.go). /healthz is the legacy static probe; prefer /livez,
+	// /readyz and /startupz (append ?verbose for per-check detail).
+	h := handlers.NewServer(handlers.Deps{
+		Config:         s.store,
+		Probes:         probes,
+		Docs:           docs,
+		Reloader:       s.reloader,
+		MetricsHandler: m.Handler(),
+		Logger:         &s.logger,
+		Build:          handlers.BuildInfo{Name: config.ServiceName, Version: settings.Service.Version, Commit: settings.Service.GitCommit},
+	})
+	routes := chi.NewRouter()
+	api.RegisterHandlers(routes, h)
+	if err := apiversion.Mount(r, routes, m, versions...); err != nil {
+		return nil, fmt.Errorf("registering API routes: %w", err)
+	}
+	// /metrics is also served here unless server.publicMetrics is off, so
+	// scrape configs written for the public port keep working.
+	if settings.Server.PublicMetrics {
+		r.Method(http.MethodGet, "/metrics", http.HandlerFunc(h.Metrics))
+	}
+	// An offline API explorer built from the spec
+	if docsOpts.Explorer {
+		r.Method(http.MethodGet, "/docs", http.RedirectHandler(apidocs.ExplorerPath, http.StatusMovedPermanently))
+		r.Method(http.MethodGet, apidocs.ExplorerPath+"*", docs.Explorer())
+	}
+	// Routes added by the embedding application
+	for _, register := range s.routes {
+		register(r)
+	}
+	s.handler = r
+
+	s.public = &http.Server{
+		Handler:           r,
+		ReadHeaderTimeout: settings.Server.ReadHeaderTimeout,
+		ReadTimeout:       settings.Server.ReadTimeout,
+		WriteTimeout:      settings.Server.WriteTimeout,
+		IdleTimeout:       settings.Server.IdleTimeout,
+		MaxHeaderBytes:    int(settings.Limits.MaxHeaderBytes),
+	}
+	if s.certs != nil {
+		s.public.TLSConfig = s.certs.TLSConfig()
+	}
+	if s.listenOpts.H2C {
+		if err := listener.EnableH2C(s.public); err != nil {
+			return nil, fmt.Errorf("enabling h2c: %w", err)
+		}
+	}
+
+	// Operational routes are only served on the admin listener.
+	s.admin = newAdminServer(s.withLogger(s.drainer.Middleware(newAdminRouter(h, m, s.tracer, policies, accessLogOpts))))
+
+	return s, nil
+}
+
+// Start opens both listeners and serves them in the background, along with
+// the secret watcher and TLS reloader. ctx bounds startup only; stop the
+// server with Shutdown. Errors serving afterwards are reported on Err.
+func (s *Server) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.stopped:
+		return errors.New("server already shut down")
+	case s.stop != nil:
+		return errors.New("server already started")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Emit a safe signal about FAKE_SECRET presence (never log the value)
+	if v := s.store.Load().Secret(config.FakeSecretName); v != "" {
+		s.logger.Info().Int("fakeSecretLen", len(v)).Msg("FAKE_SECRET present")
+	} else {
+		s.logger.Info().Msg("FAKE_SECRET not set")
+	}
+
+	ln, source, err := listener.Open(s.addr, s.listenOpts)
+	if err != nil {
+		return fmt.Errorf("opening listener: %w", err)
+	}
+	var lc net.ListenConfig
+	adminLn, err := lc.Listen(ctx, "tcp", s.adminAddr)
+	if err != nil {
+		ln.Close()
+		return fmt.Errorf("opening admin listener: %w", err)
+	}
+	s.public.Addr = ln.Addr().String()
+	s.admin.Addr = adminLn.Addr().String()
+
+	// Background work logs with the server's logger and stops at Shutdown.
+	bg, stop := context.WithCancel(s.logger.WithContext(context.Background()))
+	s.stop = stop
+	if s.watchOpts.Enabled {
+		go secrets.NewWatcher(s.reloader, s.watchOpts).Run(bg)
+	}
+	if s.certs != nil {
+		go s.certs.Run(bg)
+	}
+
+	serve := func() error { return s.public.Serve(ln) }
+	if s.certs != nil {
+		serve = func() error { return s.public.ServeTLS(ln, "", "") }
+	}
+	s.logger.Info().
+		Str("source", string(source)).
+		Str("network", ln.Addr().Network()).
+		Bool("tls", s.certs != nil).
+		Bool("h2c", s.listenOpts.H2C).
+		Int("maxConnections", s.listenOpts.MaxConnections).
+		Msgf("Listening on %s", s.public.Addr)
+	go s.serve("public", serve)
+	s.logger.Info().Msgf("Admin listener on %s", s.admin.Addr)
+	go s.serve("admin", func() error { return s.admin.Serve(adminLn) })
+
+	return nil
+}
+
+// serve runs one listener, reporting a failure on Err.
+func (s *Server) serve(name string, serve func() error) {
+	if err := serve(); err != nil && err != http.ErrServerClosed {
+		s.errs <- fmt.Errorf("%s server failed: %w", name, err)
+	}
+}
+
+// Shutdown drains both listeners (see internal/drain), stops the background
+// work and flushes pending spans. Cancelling ctx cuts the pre-stop delay
+// short; a ctx deadline bounds the wait for in-flight requests. It returns
+// an error when requests had to be aborted or spans could not be flushed.
+// A server that was never started only releases its tracer provider.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	stop, stopped := s.stop, s.stopped
+	s.stop, s.stopped = nil, true
+	s.mu.Unlock()
+	if stopped {
+		return errors.New("server already shut down")
+	}
+
+	var errs []error
+	if stop != nil {
+		if aborted := s.drainer.Drain(s.logger.WithContext(ctx), s.public, s.admin); aborted > 0 {
+			errs = append(errs, fmt.Errorf("%d in-flight requests aborted", aborted))
+		}
+		stop()
+	}
+
+	deadline := time.Now().Add(flushTimeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	flushCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if err := s.tracer.Shutdown(flushCtx); err != nil {
+		errs = append(errs, fmt.Errorf("flushing traces: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// Err reports a listener that stopped serving other than through Shutdown.
+func (s *Server) Err() <-chan error {
+	return s.errs
+}
+
+// Addr returns the public listen address: the address bound once started,
+// the configured one before.
+func (s *Server) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.public.Addr != "" {
+		return s.public.Addr
+	}
+	return s.addr
+}
+
+// AdminAddr returns the admin listen address: the address bound once
+// started, the configured one before.
+func (s *Server) AdminAddr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.admin != nil && s.admin.Addr != "" {
+		return s.admin.Addr
+	}
+	return s.adminAddr
+}
+
+// Handler returns the public router, e.g. to serve it with httptest or
+// mount it in another router without Start.
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// AdminHandler returns the admin router.
+func (s *Server) AdminHandler() http.Handler {
+	return s.admin.Handler
+}
+
+// withLogger puts the server's logger in the request context for
+// requestid.Middleware and the handlers to log with.
+func (s *Server) withLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(s.logger.WithContext(r.Context())))
+	})
+}
+
+// TracerProvider returns the provider the server records its spans with.
+// The binary installs it globally with otel.SetTracerProvider; embedders
+// may do the same when nothing else owns the global provider.
+func (s *Server) TracerProvider() trace.TracerProvider {
+	return s.tracer.TracerProvider
+}
+
+// Propagator returns the W3C tracecontext and baggage propagator the server
+// extracts inbound trace context with.
+func (s *Server) Propagator() propagation.TextMapPropagator {
+	return s.tracer.Propagator
+}
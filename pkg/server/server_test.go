@@ -0,0 +1,201 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+
+	"github.com/paulcapestany/toy-service/internal/config"
+)
+
+// testConfig returns a configuration that touches nothing outside the test.
+func testConfig(t *testing.T) Config {
+	cfg := DefaultConfig()
+	cfg.Secrets.Dir = t.TempDir()
+	cfg.Secrets.Watch = false
+	cfg.Secrets.FakeSecret = "test"
+	cfg.Shutdown.Timeout = time.Second
+	return cfg
+}
+
+func TestServer_StartShutdown(t *testing.T) {
+	var logs bytes.Buffer
+	var failing atomic.Bool
+	srv, err := New(
+		WithConfig(testConfig(t)),
+		WithAddr("127.0.0.1:0"),
+		WithAdminAddr("127.0.0.1:0"),
+		WithLogger(zerolog.New(&logs).With().Str("embedded", "yes").Logger()),
+		WithRoutes(func(r chi.Router) {
+			r.Get("/custom", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, "custom")
+			})
+		}),
+		WithMiddleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Embedded", "yes")
+				next.ServeHTTP(w, r)
+			})
+		}),
+		WithHealthCheck(HealthCheck{
+			Name:   "downstream",
+			Probes: Readiness,
+			Run: func(context.Context) error {
+				if failing.Load() {
+					return io.ErrUnexpectedEOF
+				}
+				return nil
+			},
+		}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, srv.Start(context.Background()))
+	require.Error(t, srv.Start(context.Background()))
+	require.NotEqual(t, "127.0.0.1:0", srv.Addr())
+	require.NotEqual(t, "127.0.0.1:0", srv.AdminAddr())
+	base := "http://" + srv.Addr()
+
+	t.Log("Test that the API is served under /v1 through the added middleware")
+	resp, err := http.Post(base+"/v1/echo", "application/json", strings.NewReader(`{"message":"hi"}`))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	require.Equal(t, "yes", resp.Header.Get("X-Embedded"))
+
+	t.Log("Test that extra routes are served on the public listener")
+	resp, err = http.Get(base + "/custom")
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, "custom", string(body))
+
+	t.Log("Test that extra health checks take part in readiness")
+	resp, err = http.Get(base + "/readyz?verbose")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	failing.Store(true)
+	resp, err = http.Get(base + "/readyz?verbose")
+	require.NoError(t, err)
+	var report struct {
+		Checks []struct{ Name, Status string }
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	resp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Contains(t, report.Checks, struct{ Name, Status string }{"downstream", "fail"})
+
+	t.Log("Test that the admin listener serves the operational routes")
+	resp, err = http.Get("http://" + srv.AdminAddr() + "/metrics")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	t.Log("Test that Shutdown stops both listeners and logs with the given logger")
+	require.NoError(t, srv.Shutdown(context.Background()))
+	_, err = http.Get(base + "/livez")
+	require.Error(t, err)
+	require.Contains(t, logs.String(), `"embedded":"yes"`)
+	require.Contains(t, logs.String(), "Drain finished")
+	require.Error(t, srv.Shutdown(context.Background()))
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	t.Log("Test that New validates a configuration given in code")
+
+	cfg := testConfig(t)
+	cfg.Server.Port = 0
+	_, err := New(WithConfig(cfg))
+	var verr *config.ValidationError
+	require.ErrorAs(t, err, &verr)
+}
+
+func TestNew_ConfigSource(t *testing.T) {
+	t.Log("Test that the configuration can be loaded from flags and the environment")
+
+	srv, err := New(WithConfigSource([]string{"--port", "9090"}, func(string) (string, bool) { return "", false }))
+	require.NoError(t, err)
+	require.Equal(t, ":9090", srv.Addr())
+	require.Equal(t, "127.0.0.1:8081", srv.AdminAddr())
+}
+
+func TestServer_IndependentTracing(t *testing.T) {
+	t.Log("Test that side-by-side servers trace with their own providers and leave the globals alone")
+
+	global := otel.GetTracerProvider()
+	files := make([]string, 2)
+	servers := make([]*Server, 2)
+	for i := range servers {
+		cfg := testConfig(t)
+		cfg.Tracing.Exporter = "file"
+		files[i] = filepath.Join(t.TempDir(), "traces.json")
+		cfg.Tracing.File = files[i]
+		srv, err := New(WithConfig(cfg), WithAddr("127.0.0.1:0"), WithAdminAddr("127.0.0.1:0"))
+		require.NoError(t, err)
+		require.NoError(t, srv.Start(context.Background()))
+		servers[i] = srv
+	}
+	require.Equal(t, global, otel.GetTracerProvider())
+	require.NotEqual(t, servers[0].TracerProvider(), servers[1].TracerProvider())
+
+	// Shutting down the second server must not stop the first one tracing.
+	require.NoError(t, servers[1].Shutdown(context.Background()))
+	resp, err := http.Get("http://" + servers[0].Addr() + "/v1/info")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.NoError(t, servers[0].Shutdown(context.Background()))
+
+	spans, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	require.Contains(t, string(spans), "GET /v1/info")
+}
+
+func TestServer_ShutdownWithoutStart(t *testing.T) {
+	t.Log("Test that a server that never started can still release its tracer provider")
+
+	srv, err := New(WithConfig(testConfig(t)))
+	require.NoError(t, err)
+	require.NoError(t, srv.Shutdown(context.Background()))
+	require.Error(t, srv.Shutdown(context.Background()))
+	require.Error(t, srv.Start(context.Background()))
+}
+
+func TestServer_PublicMetrics(t *testing.T) {
+	t.Log("Test that /metrics is served on the public listener unless server.publicMetrics is off")
+
+	for _, public := range []bool{true, false} {
+		cfg := testConfig(t)
+		cfg.Server.PublicMetrics = public
+		srv, err := New(WithConfig(cfg))
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if public {
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Contains(t, rec.Body.String(), "go_goroutines")
+		} else {
+			require.Equal(t, http.StatusNotFound, rec.Code)
+		}
+
+		rec = httptest.NewRecorder()
+		srv.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, srv.Shutdown(context.Background()))
+	}
+}
@@ -0,0 +1,41 @@
+// versions.go
+//
+// Declares the API versions served on the public listener and where each
+// stands in its lifecycle (see internal/apiversion). Move a route along by
+// editing its entry here; remove it once toy_service_http_deprecated_requests_total
+// shows no more traffic and its sunset has passed.
+
+package server
+
+import (
+	"time"
+
+	"github.com/paulcapestany/toy-service/internal/apiversion"
+)
+
+// currentPrefix is where the current version of the API is served.
+const currentPrefix = "/v1"
+
+// apiVersions returns the versions the API's routes are mounted under.
+// The unversioned paths predate /v1 and are kept as deprecated aliases,
+// except the probes, which Kubernetes and load balancers are configured
+// with, and the spec documents, which clients discover the versions from.
+func apiVersions() []apiversion.Version {
+	current := apiversion.Lifecycle{}
+	unversioned := apiversion.Lifecycle{
+		Deprecated: time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC),
+		Sunset:     time.Date(2027, time.April, 17, 0, 0, 0, 0, time.UTC),
+		Successor:  currentPrefix,
+	}
+	return []apiversion.Version{
+		{Name: "v1", Prefix: currentPrefix, Lifecycle: current},
+		{Name: "unversioned", Lifecycle: unversioned, Routes: map[string]apiversion.Lifecycle{
+			"/healthz":      current,
+			"/livez":        current,
+			"/readyz":       current,
+			"/startupz":     current,
+			"/openapi.yaml": current,
+			"/openapi.json": current,
+		}},
+	}
+}
@@ -0,0 +1,13 @@
+// spec.go
+//
+// Embeds the OpenAPI definition so the server validates traffic against
+// the same document the conformance tests check.
+
+package spec
+
+import _ "embed"
+
+// OpenAPI is the raw spec/openapi.yaml document.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte